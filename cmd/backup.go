@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Name of the main configuration file of a backup
+const backupConfigFile = "config.yaml"
+
+// writeBackup saves all resources discovered in Constellix to a timestamped
+// directory inside dir. The backup contains the main configuration file, so
+// it can be synced back as the expected configuration. Referenced resources
+// are saved as references (e.g. @pool:<name>), so the backup can be restored
+// after they were recreated with new IDs. Returns the path to the backup
+// directory
+func writeBackup(dir string) (string, error) {
+	backupDir := filepath.Join(dir, time.Now().UTC().Format("20060102T150405Z"))
+	err := os.MkdirAll(filepath.Join(backupDir, "dns"), 0755)
+	if err != nil {
+		return "", err
+	}
+	logger.Printf("Saving backup to %s...\n", backupDir)
+
+	var mainConfig MainConfig
+	resolver := &configResolver{keepZeroValues: true}
+	write := func(file string, collection interface{}) ([]string, error) {
+		nodes, err := resolver.configNodes(collection)
+		if err != nil {
+			return nil, err
+		}
+		err = writeDiscoveryResult(nodes, filepath.Join(backupDir, file))
+		if err != nil {
+			return nil, err
+		}
+		return []string{file}, nil
+	}
+
+	httpChecks, err := GetSonarHTTPChecks()
+	if err != nil {
+		return "", err
+	}
+	mainConfig.Constellix.Sonar.HTTPChecksConfigFiles, err = write("sonar_http_checks.yaml", httpChecks)
+	if err != nil {
+		return "", err
+	}
+
+	tcpChecks, err := GetSonarTCPChecks()
+	if err != nil {
+		return "", err
+	}
+	mainConfig.Constellix.Sonar.TCPChecksConfigFiles, err = write("sonar_tcp_checks.yaml", tcpChecks)
+	if err != nil {
+		return "", err
+	}
+
+	icmpChecks, err := GetSonarICMPChecks()
+	if err != nil {
+		return "", err
+	}
+	mainConfig.Constellix.Sonar.ICMPChecksConfigFiles, err = write("sonar_icmp_checks.yaml", icmpChecks)
+	if err != nil {
+		return "", err
+	}
+
+	dnsChecks, err := GetSonarDNSChecks()
+	if err != nil {
+		return "", err
+	}
+	mainConfig.Constellix.Sonar.DNSChecksConfigFiles, err = write("sonar_dns_checks.yaml", dnsChecks)
+	if err != nil {
+		return "", err
+	}
+
+	sslChecks, err := GetSonarSSLChecks()
+	if err != nil {
+		return "", err
+	}
+	mainConfig.Constellix.Sonar.SSLChecksConfigFiles, err = write("sonar_ssl_checks.yaml", sslChecks)
+	if err != nil {
+		return "", err
+	}
+
+	geops, err := GetGeoProximities()
+	if err != nil {
+		return "", err
+	}
+	mainConfig.Constellix.GeoProximityConfigFiles, err = write("geoproximities.yaml", geops)
+	if err != nil {
+		return "", err
+	}
+
+	filters, err := GetIPFilters()
+	if err != nil {
+		return "", err
+	}
+	mainConfig.Constellix.IPFiltersConfigFiles, err = write("ipfilters.yaml", filters)
+	if err != nil {
+		return "", err
+	}
+
+	pools, err := GetPools()
+	if err != nil {
+		return "", err
+	}
+	mainConfig.Constellix.PoolsConfigFiles, err = write("pools.yaml", pools)
+	if err != nil {
+		return "", err
+	}
+
+	// Domains are not synced, they are saved for reference only
+	domains, err := GetDNSDomains()
+	if err != nil {
+		return "", err
+	}
+	err = writeDiscoveryResult(domains, filepath.Join(backupDir, "domains.yaml"))
+	if err != nil {
+		return "", err
+	}
+	mainConfig.Constellix.DNS = map[string][]string{}
+	for _, domain := range domains {
+		records, err := GetDNSRecords(domain.ID)
+		if err != nil {
+			return "", err
+		}
+		mainConfig.Constellix.DNS[domain.Name], err = write(filepath.Join("dns", domain.Name+".yaml"), records)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	dataBytes, err := yaml.Marshal(mainConfig)
+	if err != nil {
+		return "", err
+	}
+	err = os.WriteFile(filepath.Join(backupDir, backupConfigFile), dataBytes, 0644)
+	if err != nil {
+		return "", err
+	}
+	logger.Printf("Backup saved to %s\n", backupDir)
+	return backupDir, nil
+}
+
+// getBackupConfigFile returns the main configuration file of the backup. The
+// backup can be specified by its directory or by the configuration file
+func getBackupConfigFile(backup string) (string, error) {
+	info, err := os.Stat(backup)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return backup, nil
+	}
+	configFile := filepath.Join(backup, backupConfigFile)
+	_, err = os.Stat(configFile)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a backup: %w", backup, err)
+	}
+	return configFile, nil
+}
+
+// backupBeforeApply saves a backup of all resources before changes are
+// applied, unless it is disabled with --no-backup
+func backupBeforeApply(cmd *cobra.Command) error {
+	noBackup, err := cmd.Flags().GetBool("no-backup")
+	if err != nil {
+		return err
+	}
+	if noBackup {
+		return nil
+	}
+	backupDir, err := cmd.Flags().GetString("backup-dir")
+	if err != nil {
+		return err
+	}
+	_, err = writeBackup(backupDir)
+	if err != nil {
+		return fmt.Errorf("unable to save backup before applying changes: %w", err)
+	}
+	return nil
+}
+
+// addBackupFlags adds flags which control backups made before changes are
+// applied
+func addBackupFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("backup-dir", "backups", "directory where all resources are saved before changes are applied")
+	cmd.PersistentFlags().Bool("no-backup", false, "don't save resources before changes are applied")
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func v4TestResponse(data string) string {
+	return `{"data":` + data + `,"meta":{"pagination":{"total":1,"count":1,"perPage":100,"currentPage":1,"totalPages":1}}}`
+}
+
+func Test_writeBackup_round_trip(t *testing.T) {
+	sonar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/http":
+			w.Write([]byte(`[{"id":1,"name":"web","host":"example.com","port":443,"protocolType":"HTTPS",` +
+				`"ipVersion":"IPV4","interval":"ONEMINUTE","checkSites":[1,2],"expectedStatusCode":200}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer sonar.Close()
+	dns := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/geoproximities":
+			w.Write([]byte(v4TestResponse(`[{"id":5,"name":"amsterdam","latitude":52.37,"longitude":4.89}]`)))
+		case "/domains":
+			w.Write([]byte(v4TestResponse(`[{"id":10,"name":"example.com"}]`)))
+		case "/domains/10/records":
+			w.Write([]byte(v4TestResponse(`[` +
+				`{"id":1,"name":"www","type":"A","ttl":300,"mode":"standard","region":"default","enabled":true,` +
+				`"geoproximity":{"id":5,"name":"amsterdam"},"value":[{"value":"1.1.1.1","enabled":true}]}]`)))
+		default:
+			w.Write([]byte(v4TestResponse(`[]`)))
+		}
+	}))
+	defer dns.Close()
+
+	originalSonarRESTAPIBaseURL := sonarRESTAPIBaseURL
+	originalDNSRESTAPIBaseURL := dnsRESTAPIBaseURL
+	resetCache()
+	defer func() {
+		sonarRESTAPIBaseURL = originalSonarRESTAPIBaseURL
+		dnsRESTAPIBaseURL = originalDNSRESTAPIBaseURL
+		resetCache()
+	}()
+	sonarRESTAPIBaseURL = sonar.URL
+	dnsRESTAPIBaseURL = dns.URL
+
+	backupDir, err := writeBackup(t.TempDir())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, file := range []string{"config.yaml", "domains.yaml", "sonar_http_checks.yaml", "dns/example.com.yaml"} {
+		if _, err := os.Stat(filepath.Join(backupDir, file)); err != nil {
+			t.Errorf("%s is not saved: %s", file, err)
+		}
+	}
+
+	// Restoring unchanged backup must not change anything. Empty lists of the
+	// backup are equal to nil only when the backup is restored (restoreCmd)
+	emptyEqualsNil = true
+	defer func() {
+		emptyEqualsNil = false
+	}()
+	configFile, err := getBackupConfigFile(backupDir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config, err := loadSyncConfig(configFile)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	layers, err := planSyncLayers(config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	entries := 0
+	for _, plan := range flattenLayers(layers) {
+		for _, entry := range plan.Entries {
+			entries++
+			if entry.Action != ActionOK {
+				t.Errorf("%s: want %s, got %s %+v", entry.ResourceID, ActionOK, entry.Action, entry.Diffs)
+			}
+		}
+	}
+	if entries != 3 {
+		t.Errorf("want 3 resources, got %d", entries)
+	}
+}
+
+func Test_writeBackup_restore_recreated_references(t *testing.T) {
+	// Referenced resources are recreated with other IDs before the backup is
+	// restored
+	addReferencedResources := func(api *fakeConstellixAPI, offset int) {
+		id := func(id int) string { return strconv.Itoa(id + offset) }
+		api.add("/http", `[{"id":`+id(1)+`,"name":"web","host":"192.0.2.1","port":443,"protocolType":"HTTPS",`+
+			`"ipVersion":"IPV4","interval":"ONEMINUTE","checkSites":[1]}]`)
+		api.add("/geoproximities", `[{"id":`+id(5)+`,"name":"amsterdam","latitude":52.37,"longitude":4.89}]`)
+		api.add("/ipfilters", `[{"id":`+id(3)+`,"name":"office"}]`)
+		api.add("/pools", `[{"id":`+id(7)+`,"name":"web","type":"A","return":1,"minimumFailover":1,"enabled":true,`+
+			`"values":[{"value":"192.0.2.1","weight":1,"enabled":true,"sonarCheckId":`+id(1)+`}]}]`)
+		api.add("/domains", `[{"id":10,"name":"example.com"}]`)
+	}
+	api := newFakeConstellixAPI(t)
+	addReferencedResources(api, 0)
+	api.add("/domains/10/records", `[
+{"id":1,"name":"www","type":"A","ttl":60,"mode":"standard","region":"default","enabled":true,
+ "geoproximity":{"id":5},"ipfilter":{"id":3},"value":[{"value":"192.0.2.1","enabled":true}]},
+{"id":2,"name":"pool","type":"A","ttl":60,"mode":"pools","region":"default","enabled":true,"value":[7]}]`)
+
+	backupDir, err := writeBackup(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(backupDir, "dns", "example.com.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"'@geoproximity:amsterdam'", "'@ipfilter:office'", "'@pool:web'"} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("expected %s in:\n%s", expected, data)
+		}
+	}
+
+	api = newFakeConstellixAPI(t)
+	addReferencedResources(api, 100)
+	_, err = executeCommand(rootCmd, "restore", backupDir, "--doit", "--no-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restoreCmd.PersistentFlags().Set("doit", "false")
+
+	records := api.get("/domains/10/records")
+	if len(records) != 2 {
+		t.Fatalf("want 2 restored records, got %d", len(records))
+	}
+	if len(api.created["/pools"]) != 0 || len(api.created["/http"]) != 0 {
+		t.Errorf("want recreated resources to be referenced, got new pools %v and checks %v", api.created["/pools"], api.created["/http"])
+	}
+	for _, record := range records {
+		switch record["name"] {
+		case "www":
+			geoproximity := record["geoproximity"].(map[string]interface{})
+			ipfilter := record["ipfilter"].(map[string]interface{})
+			if toInt(geoproximity["id"]) != 105 || toInt(ipfilter["id"]) != 103 {
+				t.Errorf("want references to recreated resources, got %+v", record)
+			}
+		case "pool":
+			value := record["value"].([]interface{})
+			if len(value) != 1 || toInt(value[0]) != 107 {
+				t.Errorf("want reference to recreated pool, got %+v", record)
+			}
+		}
+	}
+}
+
+func Test_dnsSyncCmd_backup_before_changes(t *testing.T) {
+	api, configFile := newDNSRollbackTestAPI(t, false)
+	backupDir := filepath.Join(t.TempDir(), "backups")
+	defer func() {
+		resetApplyFlags()
+		dnsSyncCmd.PersistentFlags().Set("backup-dir", "backups")
+	}()
+
+	// Deletion of old is refused before anything is saved
+	_, err := executeCommand(
+		rootCmd, "dns", "sync", "--config", configFile, "--doit", "--no-backup=false", "--backup-dir", backupDir,
+	)
+	if err == nil || !strings.Contains(err.Error(), "deletion is not allowed") {
+		t.Fatalf("want deletion to be refused, got %v", err)
+	}
+	if _, err := os.Stat(backupDir); !os.IsNotExist(err) {
+		t.Errorf("want no backup when changes are refused, got %v", err)
+	}
+
+	// Nothing to apply
+	configFile = writeTestConfig(t, map[string]string{
+		"config.yaml": `constellix:
+  dns:
+    example.com: [records.yaml]
+`,
+		"records.yaml": `- name: www
+  type: A
+  ttl: 60
+  mode: standard
+  region: default
+  enabled: true
+  value:
+    - value: 192.0.2.1
+      enabled: true
+- name: old
+  type: A
+  ttl: 60
+  mode: standard
+  region: default
+  enabled: true
+  value:
+    - value: 192.0.2.9
+      enabled: true
+`,
+	})
+	_, err = executeCommand(
+		rootCmd, "dns", "sync", "--config", configFile, "--doit", "--no-backup=false", "--backup-dir", backupDir,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(backupDir); !os.IsNotExist(err) {
+		t.Errorf("want no backup without changes, got %v", err)
+	}
+	if len(api.created) != 0 {
+		t.Errorf("want no changes, got %+v", api.created)
+	}
+}
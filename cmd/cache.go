@@ -1,3 +1,51 @@
 package cmd
 
 var cachedSonarHTTPChecks = make([]*SonarHTTPCheck, 0)
+var cachedSonarICMPChecks = make([]*SonarICMPCheck, 0)
+
+// cachedPools, cachedIPFilters and caches of other Sonar checks are nil until
+// they are retrieved, so empty lists are cached too and unresolved references
+// don't retrieve them again
+var cachedPools []*Pool
+var cachedIPFilters []*IPFilter
+var cachedSonarTCPChecks []*SonarTCPCheck
+var cachedSonarDNSChecks []*SonarDNSCheck
+var cachedSonarSSLChecks []*SonarSSLCheck
+
+// resetCache drops cached responses, e.g. after new resources were created
+func resetCache() {
+	cachedSonarHTTPChecks = make([]*SonarHTTPCheck, 0)
+	cachedSonarICMPChecks = make([]*SonarICMPCheck, 0)
+	cachedPools = nil
+	cachedIPFilters = nil
+	cachedSonarTCPChecks = nil
+	cachedSonarDNSChecks = nil
+	cachedSonarSSLChecks = nil
+}
+
+// When resolveFromConfig is set, references to Sonar checks, GeoProximities,
+// IP filters and Pools (e.g. @sonar,http:...) which don't exist in Constellix yet are resolved from
+// the configuration file. This allows to plan a combined sync before any of the
+// referenced resources are created. Such references get ID 0
+var resolveFromConfig bool
+var configuredSonarHTTPChecks = make([]*ExpectedSonarHTTPCheck, 0)
+var configuredSonarICMPChecks = make([]*ExpectedSonarICMPCheck, 0)
+var configuredSonarTCPChecks = make([]*ExpectedSonarTCPCheck, 0)
+var configuredSonarDNSChecks = make([]*ExpectedSonarDNSCheck, 0)
+var configuredSonarSSLChecks = make([]*ExpectedSonarSSLCheck, 0)
+var configuredGeoProximities = make([]*ExpectedGeoProximity, 0)
+var configuredPools = make([]*ExpectedPool, 0)
+var configuredIPFilters = make([]*ExpectedIPFilter, 0)
+
+// pendingReferences collects references which were resolved from the
+// configuration while a resource was parsed, see takePendingReferences
+var pendingReferences []string
+
+// takePendingReferences returns references resolved from the configuration
+// since the last call. The resource can be synced only after the referenced
+// resources are created
+func takePendingReferences() []string {
+	references := pendingReferences
+	pendingReferences = nil
+	return references
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// applyCmd applies changes saved by sync command
+var applyCmd = &cobra.Command{
+	Use:   "apply <plan file>",
+	Short: "apply changes saved with sync --plan-out",
+	Long: `Apply exactly the changes saved with 'mech sync --plan-out <plan file>'.
+
+Changes are planned again against the live state. If the live state of any
+planned resource no longer matches the plan (e.g. it was edited in Constellix
+after the plan was reviewed) or the configuration has changed, nothing is
+applied.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		// Collect flags
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+
+		document, err := readPlanFile(args[0])
+		if err != nil {
+			return err
+		}
+		if configFile == "" {
+			configFile = document.Config
+		}
+
+		config, err := loadSyncConfig(configFile)
+		if err != nil {
+			return err
+		}
+
+		layers, err := planSyncLayers(config)
+		if err != nil {
+			return err
+		}
+		plans := flattenLayers(layers)
+
+		reportPlans("", true, plans...)
+		logSyncSummary(plans...)
+
+		err = verifyPlans(document.Plans, plans)
+		if err != nil {
+			return err
+		}
+
+		err = backupBeforeApply(cmd)
+		if err != nil {
+			return err
+		}
+
+		err = applySyncLayers(layers, config, configFile, document.Remove)
+		if err != nil {
+			return err
+		}
+		err = reportSyncResults()
+		if err != nil {
+			return err
+		}
+		logger.Println("done")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	applyCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath (default: the one used to make the plan)")
+	addApplyFlags(applyCmd)
+	addBackupFlags(applyCmd)
+	addRollbackFlags(applyCmd)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// backupCmd saves all resources from Constellix
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "save all resources from Constellix to a timestamped directory",
+	Long: `Save all resources discovered in Constellix (Sonar checks, GeoProximities,
+IP filters, Pools, domains and DNS records) to a timestamped directory. The
+directory contains config.yaml, so it can be restored with the restore command.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		backupDir, err := cmd.Flags().GetString("backup-dir")
+		if err != nil {
+			return err
+		}
+		_, err = writeBackup(backupDir)
+		return err
+	},
+}
+
+// restoreCmd syncs a backup back to Constellix
+var restoreCmd = &cobra.Command{
+	Use:   "restore <backup>",
+	Short: "sync a backup back to Constellix",
+	Long: `Sync a backup, made with the backup command or before changes were applied,
+back to Constellix. The backup is used as the expected configuration, the same
+way as with 'mech sync --config <backup>/config.yaml'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		configFile, err := getBackupConfigFile(args[0])
+		if err != nil {
+			return err
+		}
+		emptyEqualsNil = true
+		defer func() {
+			emptyEqualsNil = false
+		}()
+		return runCombinedSync(cmd, configFile, "")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.PersistentFlags().String("backup-dir", "backups", "directory where the backup is saved")
+
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.PersistentFlags().Bool("doit", false, "apply planned changes")
+	restoreCmd.PersistentFlags().Bool("remove", false, "remove resources which are not present in the backup")
+	addApplyFlags(restoreCmd)
+	addBackupFlags(restoreCmd)
+	addRollbackFlags(restoreCmd)
+	addPlanOutputFlags(restoreCmd)
+}
@@ -6,9 +6,12 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
+	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
 )
 
 // dnsCmd represents the dns command
@@ -46,6 +49,11 @@ var dnsDiscoverRecordsCmd = &cobra.Command{
 			return err
 		}
 
+		asConfig, err := cmd.Flags().GetBool("as-config")
+		if err != nil {
+			return err
+		}
+
 		domains, err := GetDNSDomains()
 		if err != nil {
 			return err
@@ -71,7 +79,7 @@ var dnsDiscoverRecordsCmd = &cobra.Command{
 			return err
 		}
 		logger.Printf("Found %d DNS records\n", len(records))
-		return writeDiscoveryResult(records, outputFile)
+		return writeDiscoveredResources(records, outputFile, asConfig)
 	},
 }
 
@@ -122,7 +130,7 @@ var dnsDiscoverDomainsCmd = &cobra.Command{
 var dnsSyncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "sync configuration to Constellix",
-	RunE: func(cmd *cobra.Command, args []string) error {
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		cmd.SilenceUsage = true
 
 		// Collect flags
@@ -144,10 +152,17 @@ var dnsSyncCmd = &cobra.Command{
 			return err
 		}
 
+		err = setupPlanOutput(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { closePlanOutput(err) }()
+
 		only, err := cmd.Flags().GetString("only")
 		if err != nil {
 			return err
 		}
+
 		if only != "" {
 			logger.Printf("syncing only %s domain", only)
 		}
@@ -159,76 +174,175 @@ var dnsSyncCmd = &cobra.Command{
 
 		if len(config.DNS) == 0 {
 			logger.Println("No DNS configuration found")
-			return nil
+			return finishSync()
 		}
 
-		domains, err := GetDNSDomains()
+		plans, err := planDNSRecords(config, only)
 		if err != nil {
 			return err
 		}
+		for _, plan := range plans {
+			reportPlans(plan.Title, false, plan)
+			logSyncSummary(plan)
+		}
+		markPendingChanges(doit, plans...)
 
-		for domainName := range config.DNS {
-			if only != "" && only != domainName {
-				continue
-			}
-			var domainID int
-
-			for _, domain := range domains {
-				if domain.Name == domainName {
-					domainID = domain.ID
-				}
-			}
-
-			if domainID == 0 {
-				return fmt.Errorf("domain %s not found", domainName)
-			} else {
-				if rootVerbose {
-					logger.Printf("domain %s found with ID %d", domainName, domainID)
-				}
-			}
-			records, err := GetDNSRecords(domainID)
+		if doit {
+			err = applyDNSRecords(cmd, plans, allowRemoving)
 			if err != nil {
 				return err
 			}
-			for _, item := range config.DNS[domainName] {
-				item.domainIDInConstellix = domainID
-			}
-			activeRecords := toResourceMatcher(records)
-			expectedRecords := toResourceMatcher(config.DNS[domainName])
-			err = Sync(expectedRecords, activeRecords, doit, allowRemoving, "DNS records for "+domainName)
+		}
+		logSyncHint(doit, allowRemoving)
+		return finishSync()
+	},
+}
+
+// applyDNSRecords applies planned changes of DNS records. All resources are
+// saved to a backup right before the changes are applied and records of the
+// domains are rolled back if any of the changes fails
+func applyDNSRecords(cmd *cobra.Command, plans []*SyncPlan, allowRemoving bool) error {
+	for _, plan := range plans {
+		if !allowRemoving && len(plan.toDelete()) > 0 {
+			return fmt.Errorf("resource deletion is not allowed. Use --remove flag to allow it")
+		}
+	}
+	if summarizePlans(plans...) == (PlanSummary{}) {
+		return nil
+	}
+	err := backupBeforeApply(cmd)
+	if err != nil {
+		return err
+	}
+	return applyWithDNSRollback(plans, func() error {
+		logger.Println("Syncing changes...")
+		for _, plan := range plans {
+			err := syncChanges(plan.toDelete(), plan.toUpdate(), plan.toCreate())
 			if err != nil {
 				return err
 			}
 		}
-		var message string
-		if !doit {
-			message += "apply changes by passing --doit flag"
+		return nil
+	})
+}
+
+// dnsRollbackCmd restores DNS records from a snapshot
+var dnsRollbackCmd = &cobra.Command{
+	Use:   "rollback <snapshot>",
+	Short: "restore DNS records of a domain from a snapshot",
+	Long: `Restore DNS records of a domain from a snapshot, which is saved by
+'mech dns sync --doit' before changes are applied. Records created after the
+snapshot was taken are removed, removed records are created again and updated
+records are reverted to the snapshot values.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		doit, err := cmd.Flags().GetBool("doit")
+		if err != nil {
+			return err
+		}
+
+		snapshot, err := readDNSSnapshot(args[0])
+		if err != nil {
+			return err
 		}
-		if !allowRemoving {
-			if message != "" {
-				message += "; "
+		logger.Printf(
+			"Snapshot of %d DNS records for %s taken at %s\n",
+			len(snapshot.Records), snapshot.Domain, snapshot.CreatedAt.Format(time.RFC3339),
+		)
+
+		if !doit {
+			plan, err := planDNSRollback(snapshot)
+			if err != nil {
+				return err
 			}
-			message += "allow removing of resources by passing --remove flag"
+			reportPlans(plan.Title, false, plan)
+			logSyncSummary(plan)
+			logger.Println("apply changes by passing --doit flag")
+			return nil
 		}
-		if message == "" {
-			message = "done"
+		err = rollbackDNSRecords(snapshot)
+		if err != nil {
+			return err
 		}
-		logger.Println(message)
+		err = reportSyncResults()
+		if err != nil {
+			return err
+		}
+		logger.Println("done")
 		return nil
 	},
 }
 
+// planDNSRecords plans changes of DNS records for every domain in the
+// configuration. If only is set, other domains are skipped
+func planDNSRecords(config *Config, only string) ([]*SyncPlan, error) {
+	plans := []*SyncPlan{}
+	if len(config.DNS) == 0 {
+		return plans, nil
+	}
+
+	domains, err := GetDNSDomains()
+	if err != nil {
+		return nil, err
+	}
+
+	domainNames := maps.Keys(config.DNS)
+	sort.Strings(domainNames)
+	for _, domainName := range domainNames {
+		if only != "" && only != domainName {
+			continue
+		}
+		var domainID int
+
+		for _, domain := range domains {
+			if domain.Name == domainName {
+				domainID = domain.ID
+			}
+		}
+
+		if domainID == 0 {
+			return nil, fmt.Errorf("domain %s not found", domainName)
+		}
+		records, err := GetDNSRecords(domainID)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range config.DNS[domainName] {
+			item.domainIDInConstellix = domainID
+		}
+		// Apex NS records are not proposed for deletion, but they are kept
+		// in the snapshot of the records
+		plan, err := planSync(
+			toResourceMatcher(config.DNS[domainName]), toResourceMatcher(withoutApexNS(records)),
+			"DNS records for "+domainName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		plan.dnsDomain = &DNSDomain{ID: domainID, Name: domainName}
+		plan.dnsRecords = records
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
 func init() {
 	rootCmd.AddCommand(dnsCmd)
 	dnsCmd.AddCommand(dnsDiscoverCmd)
 	dnsDiscoverCmd.AddCommand(dnsDiscoverRecordsCmd)
+	dnsDiscoverRecordsCmd.PersistentFlags().Bool("as-config", false, "write resources in configuration format, which can be synced back without changes")
 	dnsDiscoverCmd.PersistentFlags().StringP("output", "o", "", "write output in yaml format to file, filepath")
 
 	dnsDiscoverCmd.AddCommand(dnsDiscoverDomainsCmd)
 
 	dnsCmd.AddCommand(dnsSyncCmd)
-	dnsSyncCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
-	dnsSyncCmd.PersistentFlags().Bool("doit", false, "apply planned changes")
-	dnsSyncCmd.PersistentFlags().Bool("remove", false, "remove resources which are not present in configuration file")
+	addSyncFlags(dnsSyncCmd)
 	dnsSyncCmd.PersistentFlags().String("only", "", "execute sync command only for specified domain name")
+	addRollbackFlags(dnsSyncCmd)
+
+	dnsCmd.AddCommand(dnsRollbackCmd)
+	dnsRollbackCmd.PersistentFlags().Bool("doit", false, "apply planned changes")
+	addApplyFlags(dnsRollbackCmd)
 }
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// dnsExportCmd represents the export DNS command
+var dnsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "convert DNS records in Constellix to other formats",
+}
+
+// dnsExportBindCmd writes DNS records of a domain as a BIND zone file
+var dnsExportBindCmd = &cobra.Command{
+	Use:   "bind <domain name>",
+	Short: "export DNS records of a domain as a BIND zone file",
+	Long: `Export DNS records of a domain as a BIND zone file (RFC 1035).
+Records in failover, roundrobin-failover and pools modes are rendered with
+currently served values, based on Sonar check status, and a comment describing
+the original mode. A plain DNS server serves the same answer to all clients, so
+a single record of each name and type is rendered: the one served by default
+or, if there is none, the first one served in a GTD region or to clients near a
+GeoProximity. Other records of the same name and type, HTTP redirects and
+disabled records are commented out.
+
+ANAME records are rendered as CNAME when no other records share the name.
+Otherwise they are flattened to A and AAAA records of the target, which are
+looked up in DNS at the time of the export. Logs are written to stderr when
+the zone is written to stdout.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		domain, err := getDNSDomain(args[0])
+		if err != nil {
+			return err
+		}
+		records, err := GetDNSRecords(domain.ID)
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if outputFile == "" {
+			// Keep stdout clean for the zone
+			logger.SetOutput(os.Stderr)
+			defer logger.SetOutput(os.Stdout)
+		} else {
+			f, err := os.Create(outputFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		err = renderBINDZone(w, domain, records, newActiveValueResolver())
+		if err != nil {
+			return err
+		}
+		if outputFile != "" {
+			logger.Printf("Zone %s saved to %s\n", domain.Name, outputFile)
+		}
+		return nil
+	},
+}
+
+// dnsExportOctoDNSCmd writes DNS records of a domain as octoDNS zone
+// configuration
+var dnsExportOctoDNSCmd = &cobra.Command{
+	Use:   "octodns <domain name>",
+	Short: "export DNS records of a domain as octoDNS zone configuration",
+	Long: `Export DNS records of a domain as octoDNS zone configuration.
+Records in GTD regions, failover, roundrobin-failover and pools modes are
+converted to octoDNS dynamic pools and rules. Anything which can't be
+translated (Sonar checks, GeoProximities, IP filters, HTTP redirects and
+other unsupported record types) is reported.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		domain, err := getDNSDomain(args[0])
+		if err != nil {
+			return err
+		}
+		records, err := GetDNSRecords(domain.ID)
+		if err != nil {
+			return err
+		}
+		zone, issues, err := exportOctoDNSZone(records, newActiveValueResolver())
+		if err != nil {
+			return err
+		}
+		err = writeDiscoveryResult(zone, outputFile)
+		if err != nil {
+			return err
+		}
+		reportImportIssues("Not translated", issues)
+		return nil
+	},
+}
+
+func init() {
+	dnsCmd.AddCommand(dnsExportCmd)
+	dnsExportCmd.PersistentFlags().StringP("output", "o", "", "write output to file, filepath")
+
+	dnsExportCmd.AddCommand(dnsExportBindCmd)
+	dnsExportCmd.AddCommand(dnsExportOctoDNSCmd)
+}
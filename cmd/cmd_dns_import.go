@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// dnsImportCmd represents the import DNS command
+var dnsImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "convert DNS records from other formats to mech configuration",
+}
+
+// dnsImportBindCmd converts a BIND zone file to DNS records configuration
+var dnsImportBindCmd = &cobra.Command{
+	Use:   "bind <zone file>",
+	Short: "convert a BIND zone file (RFC 1035) to DNS records configuration",
+	Long: `Convert a BIND zone file (RFC 1035) to DNS records configuration.
+Resource records with the same name and type are merged into a single record
+in standard mode. Records which can't be represented in Constellix (SOA, apex
+NS, unsupported types, names outside of the domain) are skipped and reported.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		domain, err := cmd.Flags().GetString("domain")
+		if err != nil {
+			return err
+		}
+		if domain == "" {
+			return fmt.Errorf("domain name is not defined, use --domain flag")
+		}
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		records, issues, err := parseBINDZone(f, domain)
+		if err != nil {
+			return fmt.Errorf("unable to parse zone file %s: %w", args[0], err)
+		}
+		logger.Printf("Found %d DNS records\n", len(records))
+		err = writeImportedDNSRecords(records, outputFile)
+		if err != nil {
+			return err
+		}
+		reportImportIssues("Skipped records", issues)
+		return nil
+	},
+}
+
+// dnsImportOctoDNSCmd converts octoDNS zone configuration to DNS records
+// configuration
+var dnsImportOctoDNSCmd = &cobra.Command{
+	Use:   "octodns <zone file>",
+	Short: "convert octoDNS zone configuration to DNS records configuration",
+	Long: `Convert octoDNS zone configuration to DNS records configuration.
+Rules of octoDNS geo and dynamic sections are converted to records in GTD
+regions. Dynamic pools with fallbacks are converted to failover (a single
+value in every pool) or roundrobin-failover records, Sonar checks have to be
+assigned to their values. Anything which can't be translated (subnets, geos
+outside of GTD regions, unsupported record types) is reported.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		records, issues, err := importOctoDNSZone(data)
+		if err != nil {
+			return fmt.Errorf("unable to parse octoDNS zone %s: %w", args[0], err)
+		}
+		logger.Printf("Found %d DNS records\n", len(records))
+		err = writeImportedDNSRecords(records, outputFile)
+		if err != nil {
+			return err
+		}
+		reportImportIssues("Not translated", issues)
+		return nil
+	},
+}
+
+func init() {
+	dnsCmd.AddCommand(dnsImportCmd)
+	dnsImportCmd.PersistentFlags().StringP("output", "o", "", "write output in yaml format to file, filepath")
+
+	dnsImportCmd.AddCommand(dnsImportBindCmd)
+	dnsImportBindCmd.Flags().String("domain", "", "domain name of the zone (e.g. example.com)")
+
+	dnsImportCmd.AddCommand(dnsImportOctoDNSCmd)
+}
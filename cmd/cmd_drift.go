@@ -0,0 +1,82 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// driftCmd shows resources which differ from the configuration
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "show resources in Constellix which differ from the configuration",
+	Long: `Check all resource families and show only resources which differ from the
+configuration. Nothing is changed in Constellix.
+
+With --detailed-exitcode, the command exits with 0 when there is no drift, 2
+when drift is detected and 1 on error.`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		cmd.SilenceUsage = true
+
+		// Collect flags
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		if configFile == "" {
+			return fmt.Errorf("provide configuration file location via --config argument")
+		}
+
+		err = setupPlanOutput(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { closePlanOutput(err) }()
+
+		config, err := loadSyncConfig(configFile)
+		if err != nil {
+			return err
+		}
+
+		layers, err := planSyncLayers(config)
+		if err != nil {
+			return err
+		}
+		drifted := getDriftedPlans(flattenLayers(layers))
+
+		if len(drifted) == 0 {
+			logger.Println("No drift detected")
+		} else {
+			reportPlans("", true, drifted...)
+			markPendingChanges(false, drifted...)
+		}
+		logSyncSummary(drifted...)
+		return finishSync()
+	},
+}
+
+// getDriftedPlans returns plans which contain only resources with changes
+func getDriftedPlans(plans []*SyncPlan) []*SyncPlan {
+	drifted := []*SyncPlan{}
+	for _, plan := range plans {
+		driftedPlan := &SyncPlan{Title: plan.Title}
+		for _, entry := range plan.Entries {
+			if entry.Action != ActionOK {
+				driftedPlan.Entries = append(driftedPlan.Entries, entry)
+			}
+		}
+		if len(driftedPlan.Entries) > 0 {
+			drifted = append(drifted, driftedPlan)
+		}
+	}
+	return drifted
+}
+
+func init() {
+	rootCmd.AddCommand(driftCmd)
+	driftCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
+	addPlanOutputFlags(driftCmd)
+}
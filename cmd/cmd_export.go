@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "convert resources in Constellix to configuration of other tools",
+}
+
+// exportTerraformCmd writes Terraform configuration for existing resources
+var exportTerraformCmd = &cobra.Command{
+	Use:   "terraform",
+	Short: "generate Terraform configuration for the Constellix provider",
+	Long: `Generate Terraform configuration for the Constellix provider from
+domains, DNS records, Sonar HTTP and TCP checks and GeoProximities which exist
+in Constellix. Resources are written to .tf files in the output directory
+together with imports.sh, which imports them into Terraform state. References
+to exported Sonar checks, GeoProximities and domains use Terraform resource
+addresses instead of IDs.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		outputDir, err := cmd.Flags().GetString("output-dir")
+		if err != nil {
+			return err
+		}
+		domains, err := cmd.Flags().GetStringSlice("domain")
+		if err != nil {
+			return err
+		}
+		e, err := exportTerraform(domains)
+		if err != nil {
+			return err
+		}
+		err = e.write(outputDir)
+		if err != nil {
+			return err
+		}
+		reportImportIssues("Not exported", e.issues)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportTerraformCmd)
+	exportTerraformCmd.Flags().StringP("output-dir", "o", "terraform", "directory where Terraform files are saved")
+	exportTerraformCmd.Flags().StringSlice("domain", nil, "export only specified domains (all domains by default)")
+}
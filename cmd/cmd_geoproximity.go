@@ -27,13 +27,18 @@ var geoproximityDiscoverCmd = &cobra.Command{
 			return err
 		}
 
+		asConfig, err := cmd.Flags().GetBool("as-config")
+		if err != nil {
+			return err
+		}
+
 		proximities, err := GetGeoProximities()
 		if err != nil {
 			return err
 		}
 		logger.Printf("Found %d GeoProximities\n", len(proximities))
 
-		return writeDiscoveryResult(proximities, outputFile)
+		return writeDiscoveredResources(proximities, outputFile, asConfig)
 	},
 }
 
@@ -41,7 +46,7 @@ var geoproximityDiscoverCmd = &cobra.Command{
 var geoproximitySyncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "sync configuration to Constellix",
-	RunE: func(cmd *cobra.Command, args []string) error {
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		cmd.SilenceUsage = true
 
 		// Collect flags
@@ -63,6 +68,19 @@ var geoproximitySyncCmd = &cobra.Command{
 			return err
 		}
 
+		err = setupPlanOutput(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { closePlanOutput(err) }()
+
+		if doit {
+			err = backupBeforeApply(cmd)
+			if err != nil {
+				return err
+			}
+		}
+
 		config, err := getConfig(configFile)
 		if err != nil {
 			return err
@@ -79,21 +97,8 @@ var geoproximitySyncCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		var message string
-		if !doit {
-			message += "apply changes by passing --doit flag"
-		}
-		if !allowRemoving {
-			if message != "" {
-				message += "; "
-			}
-			message += "allow removing of resources by passing --remove flag"
-		}
-		if message == "" {
-			message = "done"
-		}
-		logger.Println(message)
-		return nil
+		logSyncHint(doit, allowRemoving)
+		return finishSync()
 	},
 }
 
@@ -102,9 +107,8 @@ func init() {
 
 	geoproximityCmd.AddCommand(geoproximityDiscoverCmd)
 	geoproximityDiscoverCmd.PersistentFlags().StringP("output", "o", "", "write output in yaml format to file, filepath")
+	geoproximityDiscoverCmd.PersistentFlags().Bool("as-config", false, "write resources in configuration format, which can be synced back without changes")
 
 	geoproximityCmd.AddCommand(geoproximitySyncCmd)
-	geoproximitySyncCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
-	geoproximitySyncCmd.PersistentFlags().Bool("doit", false, "apply planned changes")
-	geoproximitySyncCmd.PersistentFlags().Bool("remove", false, "remove resources which are not present in configuration file")
+	addSyncFlags(geoproximitySyncCmd)
 }
@@ -0,0 +1,113 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// ipfilterCmd represents the ipfilter command
+var ipfilterCmd = &cobra.Command{
+	Use:   "ipfilter",
+	Short: "IP filter configuration",
+}
+
+// ipfilterDiscoverCmd represents the discover ipfilter command
+var ipfilterDiscoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "fetch IP filter configuration",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		asConfig, err := cmd.Flags().GetBool("as-config")
+		if err != nil {
+			return err
+		}
+
+		filters, err := GetIPFilters()
+		if err != nil {
+			return err
+		}
+		logger.Printf("Found %d IP filters\n", len(filters))
+
+		return writeDiscoveredResources(filters, outputFile, asConfig)
+	},
+}
+
+// ipfilterSyncCmd represents the sync ipfilter command
+var ipfilterSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "sync configuration to Constellix",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		cmd.SilenceUsage = true
+
+		// Collect flags
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		if configFile == "" {
+			return fmt.Errorf("provide configuration file location via --config argument")
+		}
+
+		doit, err := cmd.Flags().GetBool("doit")
+		if err != nil {
+			return err
+		}
+
+		allowRemoving, err := cmd.Flags().GetBool("remove")
+		if err != nil {
+			return err
+		}
+
+		err = setupPlanOutput(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { closePlanOutput(err) }()
+
+		if doit {
+			err = backupBeforeApply(cmd)
+			if err != nil {
+				return err
+			}
+		}
+
+		config, err := getConfig(configFile)
+		if err != nil {
+			return err
+		}
+
+		filters, err := GetIPFilters()
+		if err != nil {
+			return err
+		}
+		activeFilters := toResourceMatcher(filters)
+		expectedFilters := toResourceMatcher(config.IPFilters)
+		err = Sync(expectedFilters, activeFilters, doit, allowRemoving, "IP filters")
+		if err != nil {
+			return err
+		}
+		logSyncHint(doit, allowRemoving)
+		return finishSync()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ipfilterCmd)
+
+	ipfilterCmd.AddCommand(ipfilterDiscoverCmd)
+	ipfilterDiscoverCmd.PersistentFlags().StringP("output", "o", "", "write output in yaml format to file, filepath")
+	ipfilterDiscoverCmd.PersistentFlags().Bool("as-config", false, "write resources in configuration format, which can be synced back without changes")
+
+	ipfilterCmd.AddCommand(ipfilterSyncCmd)
+	addSyncFlags(ipfilterSyncCmd)
+}
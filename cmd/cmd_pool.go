@@ -0,0 +1,113 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// poolCmd represents the pool command
+var poolCmd = &cobra.Command{
+	Use:   "pool",
+	Short: "pool configuration",
+}
+
+// poolDiscoverCmd represents the discover pool command
+var poolDiscoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "fetch Pool configuration",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		asConfig, err := cmd.Flags().GetBool("as-config")
+		if err != nil {
+			return err
+		}
+
+		pools, err := GetPools()
+		if err != nil {
+			return err
+		}
+		logger.Printf("Found %d Pools\n", len(pools))
+
+		return writeDiscoveredResources(pools, outputFile, asConfig)
+	},
+}
+
+// poolSyncCmd represents the sync pool command
+var poolSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "sync configuration to Constellix",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		cmd.SilenceUsage = true
+
+		// Collect flags
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		if configFile == "" {
+			return fmt.Errorf("provide configuration file location via --config argument")
+		}
+
+		doit, err := cmd.Flags().GetBool("doit")
+		if err != nil {
+			return err
+		}
+
+		allowRemoving, err := cmd.Flags().GetBool("remove")
+		if err != nil {
+			return err
+		}
+
+		err = setupPlanOutput(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { closePlanOutput(err) }()
+
+		if doit {
+			err = backupBeforeApply(cmd)
+			if err != nil {
+				return err
+			}
+		}
+
+		config, err := getConfig(configFile)
+		if err != nil {
+			return err
+		}
+
+		pools, err := GetPools()
+		if err != nil {
+			return err
+		}
+		activePools := toResourceMatcher(pools)
+		expectedPools := toResourceMatcher(config.Pools)
+		err = Sync(expectedPools, activePools, doit, allowRemoving, "Pools")
+		if err != nil {
+			return err
+		}
+		logSyncHint(doit, allowRemoving)
+		return finishSync()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(poolCmd)
+
+	poolCmd.AddCommand(poolDiscoverCmd)
+	poolDiscoverCmd.PersistentFlags().StringP("output", "o", "", "write output in yaml format to file, filepath")
+	poolDiscoverCmd.PersistentFlags().Bool("as-config", false, "write resources in configuration format, which can be synced back without changes")
+
+	poolCmd.AddCommand(poolSyncCmd)
+	addSyncFlags(poolSyncCmd)
+}
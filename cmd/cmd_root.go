@@ -18,6 +18,9 @@ var constellixSecretKey string
 
 var logLevel int
 
+// exitCode is the exit code of the application when the command succeeds
+var exitCode int
+
 var logger *log.Logger
 var reportToTestBuffer bool
 var testBuffer *bytes.Buffer
@@ -48,6 +51,7 @@ func Execute() {
 	if err != nil {
 		os.Exit(1)
 	}
+	os.Exit(exitCode)
 }
 
 func init() {
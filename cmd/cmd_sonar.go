@@ -4,16 +4,21 @@ Copyright © 2022 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
 )
 
-var supportedSonarStaticResources = []string{"http", "tcp"}
-var supportedSonarRuntimeResources = []string{"http"}
+var supportedSonarStaticResources = []string{"http", "tcp", "icmp", "dns", "ssl"}
+var supportedSonarRuntimeResources = []string{"http", "tcp", "icmp", "dns", "ssl"}
 
 // sonarCmd represents the sonar command
 var sonarCmd = &cobra.Command{
@@ -100,6 +105,11 @@ var sonarDiscoverStaticCmd = &cobra.Command{
 			return err
 		}
 
+		asConfig, err := cmd.Flags().GetBool("as-config")
+		if err != nil {
+			return err
+		}
+
 		switch resourceType {
 		case "http":
 			httpChecks, err := GetSonarHTTPChecks()
@@ -107,14 +117,35 @@ var sonarDiscoverStaticCmd = &cobra.Command{
 				return err
 			}
 			logger.Printf("Found %d Sonar HTTP Checks\n", len(httpChecks))
-			return writeDiscoveryResult(httpChecks, outputFile)
+			return writeDiscoveredResources(httpChecks, outputFile, asConfig)
 		case "tcp":
 			tcpChecks, err := GetSonarTCPChecks()
 			if err != nil {
 				return err
 			}
-			logger.Printf("Found %d Sonar HTTP Checks\n", len(tcpChecks))
-			return writeDiscoveryResult(tcpChecks, outputFile)
+			logger.Printf("Found %d Sonar TCP Checks\n", len(tcpChecks))
+			return writeDiscoveredResources(tcpChecks, outputFile, asConfig)
+		case "icmp":
+			icmpChecks, err := GetSonarICMPChecks()
+			if err != nil {
+				return err
+			}
+			logger.Printf("Found %d Sonar ICMP Checks\n", len(icmpChecks))
+			return writeDiscoveredResources(icmpChecks, outputFile, asConfig)
+		case "dns":
+			dnsChecks, err := GetSonarDNSChecks()
+			if err != nil {
+				return err
+			}
+			logger.Printf("Found %d Sonar DNS Checks\n", len(dnsChecks))
+			return writeDiscoveredResources(dnsChecks, outputFile, asConfig)
+		case "ssl":
+			sslChecks, err := GetSonarSSLChecks()
+			if err != nil {
+				return err
+			}
+			logger.Printf("Found %d Sonar SSL Checks\n", len(sslChecks))
+			return writeDiscoveredResources(sslChecks, outputFile, asConfig)
 		default:
 			return fmt.Errorf(
 				"unsupported resource type: got %q, want one of %q",
@@ -157,42 +188,158 @@ var sonarDiscoverRuntimeCmd = &cobra.Command{
 			report.SetOutputMirror(testBuffer)
 		} else {
 			report.SetOutputMirror(os.Stdout)
-			report.AppendHeader(table.Row{"Resource", "Type", "Status"})
+			report.AppendHeader(table.Row{"Resource", "Type", "Status", "Details"})
 		}
 
-		switch resourceType {
-		case "http":
-			httpChecks, err := GetSonarHTTPChecks()
+		resourceTypes := []string{resourceType}
+		if resourceType == "all" {
+			resourceTypes = supportedSonarRuntimeResources
+		} else if !slices.Contains(supportedSonarRuntimeResources, resourceType) {
+			return fmt.Errorf(
+				"unsupported resource type: got %q, want one of %q",
+				resourceType,
+				append(supportedSonarRuntimeResources, "all"),
+			)
+		}
+
+		for _, t := range resourceTypes {
+			checks, getStatus, err := getSonarRuntimeChecks(t)
 			if err != nil {
 				return err
 			}
-			logger.Printf("Found %d Sonar HTTP Checks\n", len(httpChecks))
-			var wg sync.WaitGroup
-			for idx, check := range httpChecks {
-				wg.Add(1)
-				go func(idx int, check *SonarHTTPCheck) {
-					defer wg.Done()
-					status, err := GetSonarHTTPCheckStatus(check.ID)
-					if err != nil {
-						report.AppendRow(table.Row{
-							check.Name, "http", err.Error(),
-						})
-					} else {
-						report.AppendRow(table.Row{
-							check.Name, "http", colorStatus(status),
-						})
-					}
-				}(idx, check)
-			}
-			wg.Wait()
-			return nil
-		default:
+			appendRuntimeStatuses(report, t, checks, getStatus)
+		}
+		// Statuses are retrieved concurrently, sort by type and name
+		report.SortBy([]table.SortBy{{Number: 2}, {Number: 1}})
+		return nil
+	},
+}
+
+// SonarCheckInspection is a detailed runtime status of a Sonar check
+type SonarCheckInspection struct {
+	ID     int                   `json:"id"`
+	Name   string                `json:"name"`
+	Type   string                `json:"type"`
+	Status ResourceRuntimeStatus `json:"status"`
+	Sites  []*SiteRuntimeStatus  `json:"sites"`
+}
+
+// sonarInspectCmd shows runtime status of the check per check site
+var sonarInspectCmd = &cobra.Command{
+	Use:   "inspect <check name>",
+	Short: "retrieve runtime check's status from every check site",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		resourceType, err := cmd.Flags().GetString("type")
+		if err != nil {
+			return err
+		}
+
+		outputFormat, err := cmd.Flags().GetString("output-format")
+		if err != nil {
+			return err
+		}
+		if outputFormat != "table" && outputFormat != "json" {
+			return fmt.Errorf("unsupported output format: got %q, want one of %q", outputFormat, []string{"table", "json"})
+		}
+
+		resourceTypes := []string{resourceType}
+		if resourceType == "all" {
+			resourceTypes = supportedSonarRuntimeResources
+		} else if !slices.Contains(supportedSonarRuntimeResources, resourceType) {
 			return fmt.Errorf(
 				"unsupported resource type: got %q, want one of %q",
 				resourceType,
-				supportedSonarRuntimeResources,
+				append(supportedSonarRuntimeResources, "all"),
 			)
 		}
+
+		// Find the check by name
+		name := args[0]
+		found := []*SonarCheckInspection{}
+		for _, t := range resourceTypes {
+			checks, _, err := getSonarRuntimeChecks(t)
+			if err != nil {
+				return err
+			}
+			for id, checkName := range checks {
+				if checkName == name {
+					found = append(found, &SonarCheckInspection{ID: id, Name: name, Type: t})
+				}
+			}
+		}
+		if len(found) == 0 {
+			return fmt.Errorf("sonar check %q not found", name)
+		}
+		if len(found) > 1 {
+			types := []string{}
+			for _, item := range found {
+				types = append(types, item.Type)
+			}
+			return fmt.Errorf("found %d Sonar checks named %q of types %q, specify one with --type", len(found), name, types)
+		}
+		inspection := found[0]
+
+		status, err := getSonarCheckRuntime(inspection.Type, inspection.ID)
+		if err != nil {
+			return err
+		}
+		inspection.Status = status.Status
+		inspection.Sites = status.Sites
+		slices.SortFunc(inspection.Sites, func(a, b *SiteRuntimeStatus) int {
+			return strings.Compare(a.Site, b.Site)
+		})
+
+		var out io.Writer = os.Stdout
+		if reportToTestBuffer {
+			out = testBuffer
+		}
+
+		if outputFormat == "json" {
+			data, err := json.MarshalIndent(inspection, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(out, string(data))
+			return nil
+		}
+
+		logger.Printf(
+			"Sonar %s check %q (ID %d) is %s\n",
+			strings.ToUpper(inspection.Type), inspection.Name, inspection.ID, colorStatus(inspection.Status),
+		)
+		if len(inspection.Sites) == 0 {
+			logger.Println("  no check site data")
+			return nil
+		}
+		report := table.NewWriter()
+		report.SetOutputMirror(out)
+		if !reportToTestBuffer {
+			report.AppendHeader(table.Row{"Site", "Status", "Last check", "Response time", "Status code", "Error"})
+		}
+		for _, site := range inspection.Sites {
+			statusCode := ""
+			if site.StatusCode != 0 {
+				statusCode = strconv.Itoa(site.StatusCode)
+			}
+			report.AppendRow(table.Row{
+				site.Site,
+				colorStatus(site.Status),
+				site.LastCheck.String(),
+				fmt.Sprintf("%g ms", site.ResponseTime),
+				statusCode,
+				site.Error,
+			})
+		}
+		// For tests, render data in csv format
+		if reportToTestBuffer {
+			report.RenderCSV()
+		} else {
+			report.Render()
+		}
+		return nil
 	},
 }
 
@@ -200,7 +347,7 @@ var sonarDiscoverRuntimeCmd = &cobra.Command{
 var sonarSyncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "sync configuration to Constellix",
-	RunE: func(cmd *cobra.Command, args []string) error {
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		cmd.SilenceUsage = true
 
 		// Collect flags
@@ -222,6 +369,19 @@ var sonarSyncCmd = &cobra.Command{
 			return err
 		}
 
+		err = setupPlanOutput(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { closePlanOutput(err) }()
+
+		if doit {
+			err = backupBeforeApply(cmd)
+			if err != nil {
+				return err
+			}
+		}
+
 		config, err := getConfig(configFile)
 		if err != nil {
 			return err
@@ -250,41 +410,163 @@ var sonarSyncCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		var message string
-		if !doit {
-			message += "apply changes by passing --doit flag"
+
+		// Handle Sonar ICMP Checks
+		icmpChecks, err := GetSonarICMPChecks()
+		if err != nil {
+			return err
 		}
-		if !allowRemoving {
-			if message != "" {
-				message += "; "
-			}
-			message += "allow removing of resources by passing --remove flag"
+		activeICMPChecks := toResourceMatcher(icmpChecks)
+		expectedICMPChecks := toResourceMatcher(config.SonarICMPChecks)
+		err = Sync(expectedICMPChecks, activeICMPChecks, doit, allowRemoving, "Sonar ICMP checks")
+		if err != nil {
+			return err
 		}
-		if message == "" {
-			message = "done"
+
+		// Handle Sonar DNS Checks
+		dnsChecks, err := GetSonarDNSChecks()
+		if err != nil {
+			return err
 		}
-		logger.Println(message)
-		return nil
+		activeDNSChecks := toResourceMatcher(dnsChecks)
+		expectedDNSChecks := toResourceMatcher(config.SonarDNSChecks)
+		err = Sync(expectedDNSChecks, activeDNSChecks, doit, allowRemoving, "Sonar DNS checks")
+		if err != nil {
+			return err
+		}
+
+		// Handle Sonar SSL Checks
+		sslChecks, err := GetSonarSSLChecks()
+		if err != nil {
+			return err
+		}
+		activeSSLChecks := toResourceMatcher(sslChecks)
+		expectedSSLChecks := toResourceMatcher(config.SonarSSLChecks)
+		err = Sync(expectedSSLChecks, activeSSLChecks, doit, allowRemoving, "Sonar SSL checks")
+		if err != nil {
+			return err
+		}
+		logSyncHint(doit, allowRemoving)
+		return finishSync()
 	},
 }
 
+// getSonarRuntimeChecks returns checks of the type (mapping of check ID to its
+// name) and the function to retrieve their runtime status
+func getSonarRuntimeChecks(checkType string) (map[int]string, func(int) (ResourceRuntimeStatus, string, error), error) {
+	checks := make(map[int]string)
+	switch checkType {
+	case "http":
+		httpChecks, err := GetSonarHTTPChecks()
+		if err != nil {
+			return nil, nil, err
+		}
+		logger.Printf("Found %d Sonar HTTP Checks\n", len(httpChecks))
+		for _, check := range httpChecks {
+			checks[check.ID] = check.Name
+		}
+		return checks, withoutRuntimeDetails(GetSonarHTTPCheckStatus), nil
+	case "tcp":
+		tcpChecks, err := GetSonarTCPChecks()
+		if err != nil {
+			return nil, nil, err
+		}
+		logger.Printf("Found %d Sonar TCP Checks\n", len(tcpChecks))
+		for _, check := range tcpChecks {
+			checks[check.ID] = check.Name
+		}
+		return checks, withoutRuntimeDetails(GetSonarTCPCheckStatus), nil
+	case "icmp":
+		icmpChecks, err := GetSonarICMPChecks()
+		if err != nil {
+			return nil, nil, err
+		}
+		logger.Printf("Found %d Sonar ICMP Checks\n", len(icmpChecks))
+		for _, check := range icmpChecks {
+			checks[check.ID] = check.Name
+		}
+		return checks, withoutRuntimeDetails(GetSonarICMPCheckStatus), nil
+	case "dns":
+		dnsChecks, err := GetSonarDNSChecks()
+		if err != nil {
+			return nil, nil, err
+		}
+		logger.Printf("Found %d Sonar DNS Checks\n", len(dnsChecks))
+		for _, check := range dnsChecks {
+			checks[check.ID] = check.Name
+		}
+		return checks, withoutRuntimeDetails(GetSonarDNSCheckStatus), nil
+	case "ssl":
+		sslChecks, err := GetSonarSSLChecks()
+		if err != nil {
+			return nil, nil, err
+		}
+		logger.Printf("Found %d Sonar SSL Checks\n", len(sslChecks))
+		for _, check := range sslChecks {
+			checks[check.ID] = check.Name
+		}
+		return checks, GetSonarSSLCheckStatus, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported resource type: %q", checkType)
+}
+
+// appendRuntimeStatuses retrieves runtime status of the checks (mapping of
+// check ID to its name) concurrently and appends it to the report. getStatus
+// returns the status and optional details (e.g. days until certificate expires)
+func appendRuntimeStatuses(report table.Writer, checkType string, checks map[int]string, getStatus func(int) (ResourceRuntimeStatus, string, error)) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for id, name := range checks {
+		wg.Add(1)
+		go func(id int, name string) {
+			defer wg.Done()
+			status, details, err := getStatus(id)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				report.AppendRow(table.Row{
+					name, checkType, err.Error(), "",
+				})
+			} else {
+				report.AppendRow(table.Row{
+					name, checkType, colorStatus(status), details,
+				})
+			}
+		}(id, name)
+	}
+	wg.Wait()
+}
+
+// withoutRuntimeDetails adapts status getters which don't provide any details
+func withoutRuntimeDetails(getStatus func(int) (ResourceRuntimeStatus, error)) func(int) (ResourceRuntimeStatus, string, error) {
+	return func(id int) (ResourceRuntimeStatus, string, error) {
+		status, err := getStatus(id)
+		return status, "", err
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(sonarCmd)
 	sonarCmd.AddCommand(sonarDiscoverCmd)
 	sonarDiscoverCmd.PersistentFlags().StringP("output", "o", "", "write output in yaml format to file, filepath")
 
 	sonarDiscoverCmd.AddCommand(sonarDiscoverStaticCmd)
+	sonarDiscoverStaticCmd.PersistentFlags().Bool("as-config", false, "write resources in configuration format, which can be synced back without changes")
 	sonarDiscoverStaticCmd.PersistentFlags().StringP(
 		"type", "t", "http", fmt.Sprintf("specify static resource type, one of %q", supportedSonarStaticResources),
 	)
 
 	sonarDiscoverCmd.AddCommand(sonarDiscoverRuntimeCmd)
 	sonarDiscoverRuntimeCmd.PersistentFlags().StringP(
-		"type", "t", "http", fmt.Sprintf("specify runtime resource type, one of %q", supportedSonarRuntimeResources),
+		"type", "t", "http", fmt.Sprintf("specify runtime resource type, one of %q or \"all\"", supportedSonarRuntimeResources),
+	)
+
+	sonarCmd.AddCommand(sonarInspectCmd)
+	sonarInspectCmd.PersistentFlags().StringP(
+		"type", "t", "all", fmt.Sprintf("specify runtime resource type, one of %q or \"all\"", supportedSonarRuntimeResources),
 	)
+	sonarInspectCmd.PersistentFlags().StringP("output-format", "f", "table", "output format, one of [\"table\", \"json\"]")
 
 	sonarCmd.AddCommand(sonarSyncCmd)
-	sonarSyncCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
-	sonarSyncCmd.PersistentFlags().Bool("doit", false, "apply planned changes")
-	sonarSyncCmd.PersistentFlags().Bool("remove", false, "remove resources which are not present in configuration file")
+	addSyncFlags(sonarSyncCmd)
 }
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -32,3 +35,108 @@ func Test_SonarDiscoverStaticCmd_illegal_type(t *testing.T) {
 		t.Errorf("Expected error message, got %s", output)
 	}
 }
+
+func Test_SonarDiscoverRuntimeCmd_all(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/http":
+			w.Write([]byte(`[{"id":1,"name":"web"}]`))
+		case "/tcp":
+			w.Write([]byte(`[{"id":2,"name":"db"}]`))
+		case "/http/1/status":
+			w.Write([]byte(`{"status":"UP"}`))
+		case "/tcp/2/status":
+			w.Write([]byte(`{"status":"DOWN"}`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+
+	originalSonarRESTAPIBaseURL := sonarRESTAPIBaseURL
+	reportToTestBuffer = true
+	resetCache()
+	defer func() {
+		sonarRESTAPIBaseURL = originalSonarRESTAPIBaseURL
+		reportToTestBuffer = false
+		testBuffer.Reset()
+		resetCache()
+	}()
+	sonarRESTAPIBaseURL = ts.URL
+
+	_, err := executeCommand(rootCmd, "sonar", "discover", "runtime", "--type", "all")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	output := stripBashColors(testBuffer.String())
+	expected := "web,http,UP,\ndb,tcp,DOWN,\n"
+	if output != expected {
+		t.Errorf("want %q, got %q", expected, output)
+	}
+}
+
+func Test_SonarInspectCmd(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/http":
+			w.Write([]byte(`[{"id":1,"name":"web"}]`))
+		case "/http/1/status":
+			w.Write([]byte(`{"status":"DOWN","checkSites":[
+				{"siteName":"London","status":"DOWN","lastCheck":"2024-01-01T12:00:00Z","responseTime":0,"statusCode":503,"errorMessage":"Service Unavailable"},
+				{"siteName":"Amsterdam","status":"UP","lastCheck":1704110400000,"responseTime":42,"statusCode":200,"errorMessage":""}
+			]}`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+
+	originalSonarRESTAPIBaseURL := sonarRESTAPIBaseURL
+	reportToTestBuffer = true
+	resetCache()
+	defer func() {
+		sonarRESTAPIBaseURL = originalSonarRESTAPIBaseURL
+		reportToTestBuffer = false
+		testBuffer.Reset()
+		resetCache()
+	}()
+	sonarRESTAPIBaseURL = ts.URL
+
+	_, err := executeCommand(rootCmd, "sonar", "inspect", "web", "--output-format", "table")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	output := stripBashColors(testBuffer.String())
+	expected := "Amsterdam,UP,2024-01-01T12:00:00Z,42 ms,200,\n" +
+		"London,DOWN,2024-01-01T12:00:00Z,0 ms,503,Service Unavailable\n"
+	if output != expected {
+		t.Errorf("want %q, got %q", expected, output)
+	}
+
+	testBuffer.Reset()
+	_, err = executeCommand(rootCmd, "sonar", "inspect", "web", "--output-format", "json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	inspection := SonarCheckInspection{}
+	err = json.Unmarshal(testBuffer.Bytes(), &inspection)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if inspection.ID != 1 || inspection.Type != "http" || inspection.Status != StatusDown || len(inspection.Sites) != 2 {
+		t.Errorf("unexpected inspection: %+v", inspection)
+		return
+	}
+	if inspection.Sites[1].StatusCode != 503 || inspection.Sites[1].Error != "Service Unavailable" {
+		t.Errorf("unexpected site: %+v", inspection.Sites[1])
+	}
+
+	_, err = executeCommand(rootCmd, "sonar", "inspect", "missing", "--output-format", "table")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
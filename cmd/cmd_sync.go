@@ -0,0 +1,353 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Resources are synced in layers. Resources of a layer may reference resources
+// of the previous layers (e.g. DNS records reference Sonar checks and Pools)
+const (
+	syncLayerChecks  = iota // Sonar checks, GeoProximities and IP filters
+	syncLayerPools          // Pools, may reference Sonar checks
+	syncLayerRecords        // DNS records, may reference all of the above
+	syncLayersCount
+)
+
+// syncCmd syncs all supported resources in dependency order
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "sync Sonar checks, GeoProximities, IP filters, Pools and DNS records to Constellix",
+	Long: `Sync all resources defined in the configuration file in one run.
+
+Resources are applied in dependency order, because DNS records may reference
+Sonar checks, GeoProximities, IP filters and Pools:
+  1. Sonar checks, GeoProximities and IP filters are created and updated
+  2. Pools are created and updated
+  3. DNS records are removed, updated and created
+  4. Stale Pools, Sonar checks, GeoProximities and IP filters are removed`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		// Collect flags
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		if configFile == "" {
+			return fmt.Errorf("provide configuration file location via --config argument")
+		}
+
+		planOut, err := cmd.Flags().GetString("plan-out")
+		if err != nil {
+			return err
+		}
+		return runCombinedSync(cmd, configFile, planOut)
+	},
+}
+
+// runCombinedSync plans changes of all resources in the configuration file and
+// applies them in dependency order. If planOut is set, the plan is saved to it
+func runCombinedSync(cmd *cobra.Command, configFile string, planOut string) (err error) {
+	doit, err := cmd.Flags().GetBool("doit")
+	if err != nil {
+		return err
+	}
+
+	allowRemoving, err := cmd.Flags().GetBool("remove")
+	if err != nil {
+		return err
+	}
+
+	err = setupPlanOutput(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { closePlanOutput(err) }()
+
+	config, err := loadSyncConfig(configFile)
+	if err != nil {
+		return err
+	}
+
+	layers, err := planSyncLayers(config)
+	if err != nil {
+		return err
+	}
+	plans := flattenLayers(layers)
+
+	reportPlans("", true, plans...)
+	logSyncSummary(plans...)
+	markPendingChanges(doit, plans...)
+
+	if planOut != "" {
+		err = writePlanFile(planOut, configFile, allowRemoving, plans)
+		if err != nil {
+			return err
+		}
+	}
+
+	if doit {
+		err = backupBeforeApply(cmd)
+		if err != nil {
+			return err
+		}
+		err = applySyncLayers(layers, config, configFile, allowRemoving)
+		if err != nil {
+			return err
+		}
+	}
+	logSyncHint(doit, allowRemoving)
+	return finishSync()
+}
+
+// loadSyncConfig reads the configuration for the combined sync. References
+// are resolved from the configuration as well, because the referenced
+// resources might not exist yet
+func loadSyncConfig(configFile string) (*Config, error) {
+	resolveFromConfig = true
+	defer func() {
+		resolveFromConfig = false
+	}()
+	return getConfig(configFile)
+}
+
+// planSyncLayers plans changes of all layers
+func planSyncLayers(config *Config) ([][]*SyncPlan, error) {
+	var err error
+	layers := make([][]*SyncPlan, syncLayersCount)
+	for layer := range layers {
+		layers[layer], err = planSyncLayer(config, layer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return layers, nil
+}
+
+// flattenLayers returns plans of all layers in dependency order
+func flattenLayers(layers [][]*SyncPlan) []*SyncPlan {
+	plans := []*SyncPlan{}
+	for _, layer := range layers {
+		plans = append(plans, layer...)
+	}
+	return plans
+}
+
+// applySyncLayers applies planned layers. Layers are planned again from
+// configFile when the previous layers create new resources. DNS records of the
+// configured domains are rolled back if any of the changes fails
+func applySyncLayers(layers [][]*SyncPlan, config *Config, configFile string, allowRemoving bool) error {
+	for _, plan := range flattenLayers(layers) {
+		if !allowRemoving && len(plan.toDelete()) > 0 {
+			return fmt.Errorf("resource deletion is not allowed. Use --remove flag to allow it")
+		}
+	}
+	return applyWithDNSRollback(layers[syncLayerRecords], func() error {
+		logger.Println("Syncing changes...")
+		return syncAll(layers, func(layer int) ([]*SyncPlan, error) {
+			// References have to be resolved again to get IDs of the
+			// resources which have just been created. Resources of the next
+			// layers don't exist yet, they are still resolved from the
+			// configuration
+			logger.Println("Planning changes with newly created resources...")
+			resetCache()
+			config, err := loadSyncConfig(configFile)
+			if err != nil {
+				return nil, err
+			}
+			replanned, err := planSyncLayer(config, layer)
+			if err != nil {
+				return nil, err
+			}
+			logSyncSummary(replanned...)
+			return replanned, nil
+		})
+	})
+}
+
+// planSyncLayer plans changes of all resources in the layer
+func planSyncLayer(config *Config, layer int) ([]*SyncPlan, error) {
+	switch layer {
+	case syncLayerChecks:
+		httpChecks, err := GetSonarHTTPChecks()
+		if err != nil {
+			return nil, err
+		}
+		httpPlan, err := planSync(
+			toResourceMatcher(config.SonarHTTPChecks), toResourceMatcher(httpChecks), "Sonar HTTP checks",
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		tcpChecks, err := GetSonarTCPChecks()
+		if err != nil {
+			return nil, err
+		}
+		tcpPlan, err := planSync(
+			toResourceMatcher(config.SonarTCPChecks), toResourceMatcher(tcpChecks), "Sonar TCP checks",
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		icmpChecks, err := GetSonarICMPChecks()
+		if err != nil {
+			return nil, err
+		}
+		icmpPlan, err := planSync(
+			toResourceMatcher(config.SonarICMPChecks), toResourceMatcher(icmpChecks), "Sonar ICMP checks",
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		dnsChecks, err := GetSonarDNSChecks()
+		if err != nil {
+			return nil, err
+		}
+		dnsPlan, err := planSync(
+			toResourceMatcher(config.SonarDNSChecks), toResourceMatcher(dnsChecks), "Sonar DNS checks",
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sslChecks, err := GetSonarSSLChecks()
+		if err != nil {
+			return nil, err
+		}
+		sslPlan, err := planSync(
+			toResourceMatcher(config.SonarSSLChecks), toResourceMatcher(sslChecks), "Sonar SSL checks",
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		geops, err := GetGeoProximities()
+		if err != nil {
+			return nil, err
+		}
+		geoPlan, err := planSync(
+			toResourceMatcher(config.GeoProximities), toResourceMatcher(geops), "Geoproximities",
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		filters, err := GetIPFilters()
+		if err != nil {
+			return nil, err
+		}
+		filterPlan, err := planSync(
+			toResourceMatcher(config.IPFilters), toResourceMatcher(filters), "IP filters",
+		)
+		if err != nil {
+			return nil, err
+		}
+		return []*SyncPlan{httpPlan, tcpPlan, icmpPlan, dnsPlan, sslPlan, geoPlan, filterPlan}, nil
+	case syncLayerPools:
+		pools, err := GetPools()
+		if err != nil {
+			return nil, err
+		}
+		poolPlan, err := planSync(toResourceMatcher(config.Pools), toResourceMatcher(pools), "Pools")
+		if err != nil {
+			return nil, err
+		}
+		return []*SyncPlan{poolPlan}, nil
+	case syncLayerRecords:
+		return planDNSRecords(config, "")
+	}
+	return nil, fmt.Errorf("unknown sync layer %d", layer)
+}
+
+// syncAll applies layers of plans in dependency order. Layers are created and
+// updated top-down and stale resources are removed bottom-up, when nothing
+// references them anymore. The last layer is applied at once. If any of the
+// previous layers created new resources, the layer is planned again by replan
+// before it is applied. Resources referencing resources which failed to be
+// created (--keep-going) are skipped and reported as failed
+func syncAll(layers [][]*SyncPlan, replan func(layer int) ([]*SyncPlan, error)) error {
+	created := false
+	last := len(layers) - 1
+	for layer := range layers {
+		if created {
+			plans, err := replan(layer)
+			if err != nil {
+				return err
+			}
+			layers[layer] = plans
+		}
+		for _, plan := range layers[layer] {
+			var toDelete []IActiveResource
+			if layer == last {
+				toDelete = plan.toDelete()
+			}
+			toUpdate, toCreate, err := skipDependantsOfFailedCreates(plan)
+			if err != nil {
+				return err
+			}
+			createdBefore := countCreatedSyncResources()
+			err = syncChanges(toDelete, toUpdate, toCreate)
+			if err != nil {
+				return err
+			}
+			if countCreatedSyncResources() > createdBefore {
+				created = true
+			}
+		}
+	}
+
+	for layer := last - 1; layer >= 0; layer-- {
+		for _, plan := range layers[layer] {
+			err := syncChanges(plan.toDelete(), nil, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipDependantsOfFailedCreates returns planned updates and creates of the
+// plan without resources which still reference resources that don't exist.
+// Previous layers are already applied, so creating the referenced resources
+// failed. Skipped changes are reported as failed
+func skipDependantsOfFailedCreates(plan *SyncPlan) (map[IExpectedResource]int, []IExpectedResource, error) {
+	toUpdate := plan.toUpdate()
+	toCreate := []IExpectedResource{}
+	for _, entry := range plan.Entries {
+		if entry.Action != ActionUpate && entry.Action != ActionCreate {
+			continue
+		}
+		dependent, ok := entry.expected.(IDependentResource)
+		if !ok || len(dependent.GetPendingReferences()) == 0 {
+			if entry.Action == ActionCreate {
+				toCreate = append(toCreate, entry.expected)
+			}
+			continue
+		}
+		delete(toUpdate, entry.expected)
+		err := recordSyncResult(entry.Action, entry.expected, fmt.Errorf(
+			"skipped, referenced %s failed to be created", strings.Join(dependent.GetPendingReferences(), ", "),
+		))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return toUpdate, toCreate, nil
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	addSyncFlags(syncCmd)
+	addRollbackFlags(syncCmd)
+	syncCmd.PersistentFlags().String("plan-out", "", "save planned changes to file which can be applied with apply command, filepath")
+}
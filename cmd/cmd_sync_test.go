@@ -0,0 +1,492 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeConstellixAPI is a stateful fake of Sonar and DNS APIs. Resources are
+// kept as JSON objects by collection path, e.g. /http or /domains/10/records
+type fakeConstellixAPI struct {
+	mu          sync.Mutex
+	nextID      int
+	collections map[string][]map[string]interface{}
+	// Payloads of created resources by collection path
+	created map[string][]map[string]interface{}
+	// Requests which fail with the status code, e.g. "POST /pools"
+	failures map[string]int
+	sonar    *httptest.Server
+	dns      *httptest.Server
+}
+
+// newFakeConstellixAPI starts the fake API and points the API base URLs to it.
+// DNS snapshots are saved to a temporary directory
+func newFakeConstellixAPI(t *testing.T) *fakeConstellixAPI {
+	api := &fakeConstellixAPI{
+		nextID:      100,
+		collections: map[string][]map[string]interface{}{},
+		created:     map[string][]map[string]interface{}{},
+		failures:    map[string]int{},
+	}
+	api.sonar = httptest.NewServer(api.handler(false))
+	api.dns = httptest.NewServer(api.handler(true))
+	originalSonarRESTAPIBaseURL := sonarRESTAPIBaseURL
+	originalDNSRESTAPIBaseURL := dnsRESTAPIBaseURL
+	originalDNSSnapshotDir := dnsSnapshotDir
+	resetCache()
+	t.Cleanup(func() {
+		api.sonar.Close()
+		api.dns.Close()
+		sonarRESTAPIBaseURL = originalSonarRESTAPIBaseURL
+		dnsRESTAPIBaseURL = originalDNSRESTAPIBaseURL
+		dnsSnapshotDir = originalDNSSnapshotDir
+		resetCache()
+	})
+	sonarRESTAPIBaseURL = api.sonar.URL
+	dnsRESTAPIBaseURL = api.dns.URL
+	dnsSnapshotDir = t.TempDir()
+	return api
+}
+
+// add adds resources to the collection, the resources must have IDs
+func (api *fakeConstellixAPI) add(path string, data string) {
+	var items []map[string]interface{}
+	err := json.Unmarshal([]byte(data), &items)
+	if err != nil {
+		panic(err)
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.collections[path] = append(api.collections[path], items...)
+}
+
+// get returns resources of the collection
+func (api *fakeConstellixAPI) get(path string) []map[string]interface{} {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.collections[path]
+}
+
+func (api *fakeConstellixAPI) handler(v4 bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		reply := func(status int, data interface{}) {
+			w.WriteHeader(status)
+			if data == nil {
+				return
+			}
+			body, _ := json.Marshal(data)
+			if v4 {
+				body = []byte(v4TestResponse(string(body)))
+			}
+			w.Write(body)
+		}
+		if status, ok := api.failures[r.Method+" "+r.URL.Path]; ok {
+			reply(status, nil)
+			return
+		}
+
+		collection, id := r.URL.Path, 0
+		if idx := strings.LastIndex(r.URL.Path, "/"); idx > 0 {
+			if n, err := strconv.Atoi(r.URL.Path[idx+1:]); err == nil {
+				collection, id = r.URL.Path[:idx], n
+			}
+		}
+		var payload map[string]interface{}
+		body, _ := io.ReadAll(r.Body)
+		if len(body) > 0 {
+			json.Unmarshal(body, &payload)
+		}
+		// References are returned as objects by the DNS API
+		for _, field := range []string{"geoproximity", "ipfilter"} {
+			if ref, ok := payload[field].(float64); ok {
+				payload[field] = map[string]interface{}{"id": ref}
+			}
+		}
+
+		switch r.Method {
+		case "GET":
+			items := api.collections[collection]
+			if items == nil {
+				items = []map[string]interface{}{}
+			}
+			reply(200, items)
+		case "POST":
+			api.nextID++
+			payload["id"] = float64(api.nextID)
+			api.collections[collection] = append(api.collections[collection], payload)
+			api.created[collection] = append(api.created[collection], payload)
+			switch {
+			case !v4:
+				reply(201, nil)
+			case collection == "/geoproximities" || strings.HasSuffix(collection, "/records"):
+				reply(202, nil)
+			default:
+				reply(201, nil)
+			}
+		case "PUT", "PATCH":
+			for _, item := range api.collections[collection] {
+				if toInt(item["id"]) == id {
+					for k, v := range payload {
+						item[k] = v
+					}
+				}
+			}
+			reply(200, nil)
+		case "DELETE":
+			items := []map[string]interface{}{}
+			for _, item := range api.collections[collection] {
+				if toInt(item["id"]) != id {
+					items = append(items, item)
+				}
+			}
+			api.collections[collection] = items
+			if v4 {
+				reply(204, nil)
+			} else {
+				reply(202, nil)
+			}
+		}
+	}
+}
+
+// writeTestConfig writes configuration files to a temporary directory and
+// returns the path to the main configuration file
+func writeTestConfig(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, data := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "config.yaml")
+}
+
+func Test_syncAll_dependency_order(t *testing.T) {
+	createdCheck := &testExpectedResource{Name: "check-new"}
+	staleCheck := &testActiveResource{Name: "check-stale", constellixID: 1}
+	prerequisite, err := planSync(
+		toResourceMatcher([]*testExpectedResource{createdCheck}),
+		toResourceMatcher([]*testActiveResource{staleCheck}),
+		"checks",
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	record := &testExpectedResource{Name: "record"}
+	dependent, err := planSync(toResourceMatcher([]*testExpectedResource{record}), nil, "records")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	replanned := false
+	err = syncAll([][]*SyncPlan{{prerequisite}, {dependent}}, func(layer int) ([]*SyncPlan, error) {
+		replanned = true
+		if layer != 1 {
+			t.Errorf("want layer 1 to be planned again, got %d", layer)
+		}
+		if len(createdCheck.syncCalls) != 1 {
+			t.Errorf("want prerequisites to be created before dependents, got %d calls", len(createdCheck.syncCalls))
+		}
+		if len(staleCheck.syncCalls) != 0 {
+			t.Errorf("want prerequisites to be removed after dependents, got %q", staleCheck.syncCalls)
+		}
+		return []*SyncPlan{dependent}, nil
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !replanned {
+		t.Error("want dependents to be planned again")
+		return
+	}
+	if len(record.syncCalls) != 1 || record.syncCalls[0] != "create" {
+		t.Errorf("want create call, got %q", record.syncCalls)
+		return
+	}
+	if len(staleCheck.syncCalls) != 1 || staleCheck.syncCalls[0] != "delete:1" {
+		t.Errorf("want delete:1 call, got %q", staleCheck.syncCalls)
+		return
+	}
+}
+
+func Test_syncCmd_creates_referenced_resources(t *testing.T) {
+	api := newFakeConstellixAPI(t)
+	api.add("/domains", `[{"id":10,"name":"example.com"}]`)
+	configFile := writeTestConfig(t, map[string]string{
+		"config.yaml": `constellix:
+  sonar:
+    http_checks: [checks.yaml]
+  pools: [pools.yaml]
+  dns:
+    example.com: [records.yaml]
+`,
+		"checks.yaml": `- name: web
+  host: 192.0.2.1
+  ipVersion: IPV4
+  port: 443
+  protocolType: HTTPS
+  interval: ONEMINUTE
+  checkSites: [1]
+`,
+		"pools.yaml": `- name: web
+  type: A
+  return: 1
+  minimumFailover: 1
+  enabled: true
+  values:
+    - value: 192.0.2.1
+      weight: 1
+      enabled: true
+      sonarCheckId: "@sonar,http:web"
+`,
+		"records.yaml": `- name: www
+  type: A
+  ttl: 60
+  mode: pools
+  region: default
+  enabled: true
+  value: ["@pool:web"]
+`,
+	})
+
+	_, err := executeCommand(rootCmd, "sync", "--config", configFile, "--doit", "--no-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checks := api.get("/http")
+	pools := api.get("/pools")
+	records := api.get("/domains/10/records")
+	if len(checks) != 1 || len(pools) != 1 || len(records) != 1 {
+		t.Fatalf("want 1 check, 1 pool and 1 record, got %d, %d and %d", len(checks), len(pools), len(records))
+	}
+	poolValue := pools[0]["values"].([]interface{})[0].(map[string]interface{})
+	if toInt(poolValue["sonarCheckId"]) != toInt(checks[0]["id"]) {
+		t.Errorf("want pool to reference check %v, got %v", checks[0]["id"], poolValue["sonarCheckId"])
+	}
+	recordValue := records[0]["value"].([]interface{})
+	if len(recordValue) != 1 || toInt(recordValue[0]) != toInt(pools[0]["id"]) {
+		t.Errorf("want record to reference pool %v, got %v", pools[0]["id"], recordValue)
+	}
+	if fmt.Sprint(records[0]["name"]) != "www" {
+		t.Errorf("unexpected record %+v", records[0])
+	}
+}
+
+func Test_syncCmd_skips_dependants_of_failed_creates(t *testing.T) {
+	api := newFakeConstellixAPI(t)
+	api.add("/domains", `[{"id":10,"name":"example.com"}]`)
+	api.failures["POST /http"] = http.StatusBadRequest
+	configFile := writeTestConfig(t, map[string]string{
+		"config.yaml": `constellix:
+  sonar:
+    http_checks: [checks.yaml]
+  pools: [pools.yaml]
+  dns:
+    example.com: [records.yaml]
+`,
+		"checks.yaml": `- name: web
+  host: 192.0.2.1
+  ipVersion: IPV4
+  port: 443
+  protocolType: HTTPS
+  interval: ONEMINUTE
+  checkSites: [1]
+`,
+		"pools.yaml": `- name: web
+  type: A
+  return: 1
+  minimumFailover: 1
+  enabled: true
+  values:
+    - value: 192.0.2.1
+      weight: 1
+      enabled: true
+      sonarCheckId: "@sonar,http:web"
+`,
+		"records.yaml": `- name: www
+  type: A
+  ttl: 60
+  mode: pools
+  region: default
+  enabled: true
+  value: ["@pool:web"]
+- name: api
+  type: A
+  ttl: 60
+  mode: standard
+  region: default
+  enabled: true
+  value:
+    - value: 192.0.2.3
+      enabled: true
+`,
+	})
+	defer resetApplyFlags()
+
+	_, err := executeCommand(rootCmd, "sync", "--config", configFile, "--doit", "--no-backup", "--keep-going")
+	if err == nil || !strings.Contains(err.Error(), "3 of 4 changes failed") {
+		t.Fatalf("want the check and its dependants to fail, got %v", err)
+	}
+	if len(api.created["/pools"]) != 0 {
+		t.Errorf("want pool referencing the failed check to be skipped, got %+v", api.created["/pools"])
+	}
+	for _, record := range api.created["/domains/10/records"] {
+		if record["name"] == "www" {
+			t.Errorf("want record referencing the skipped pool to be skipped, got %+v", record)
+		}
+	}
+	if len(api.created["/domains/10/records"]) != 1 {
+		t.Errorf("want independent record to be created, got %+v", api.created["/domains/10/records"])
+	}
+}
+
+func Test_syncCmd_writes_plan_document_on_error(t *testing.T) {
+	api := newFakeConstellixAPI(t)
+	api.failures["POST /pools"] = http.StatusBadRequest
+	configFile := writeTestConfig(t, map[string]string{
+		"config.yaml": `constellix:
+  pools: [pools.yaml]
+`,
+		"pools.yaml": `- name: web
+  type: A
+  return: 1
+  minimumFailover: 1
+  enabled: true
+  values:
+    - value: 192.0.2.1
+      weight: 1
+      enabled: true
+`,
+	})
+	reportToTestBuffer = true
+	defer func() {
+		reportToTestBuffer = false
+		testBuffer.Reset()
+		syncCmd.PersistentFlags().Set("output-format", planOutputTable)
+		syncCmd.PersistentFlags().Set("doit", "false")
+	}()
+
+	_, err := executeCommand(rootCmd, "sync", "--config", configFile, "--doit", "--no-backup", "--output-format", "json")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	var document PlanDocument
+	err = json.Unmarshal(testBuffer.Bytes(), &document)
+	if err != nil {
+		t.Fatalf("want plan document, got %q: %s", testBuffer.String(), err)
+	}
+	if !strings.Contains(document.Error, "unexpected status code 400") {
+		t.Errorf("want error in plan document, got %q", document.Error)
+	}
+	if document.Summary.Create != 1 || len(document.Plans) == 0 {
+		t.Errorf("want planned changes in plan document, got %+v", document)
+	}
+}
+
+func Test_syncCmd_detailed_exitcode_doit(t *testing.T) {
+	newFakeConstellixAPI(t)
+	configFile := writeTestConfig(t, map[string]string{
+		"config.yaml": `constellix:
+  pools: [pools.yaml]
+`,
+		"pools.yaml": `- name: web
+  type: A
+  return: 1
+  minimumFailover: 1
+  enabled: true
+  values:
+    - value: 192.0.2.1
+      weight: 1
+      enabled: true
+`,
+	})
+	defer func() {
+		exitCode = 0
+		syncCmd.PersistentFlags().Set("detailed-exitcode", "false")
+		syncCmd.PersistentFlags().Set("doit", "false")
+	}()
+
+	_, err := executeCommand(rootCmd, "sync", "--config", configFile, "--detailed-exitcode", "--doit=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != exitCodeChangesPending {
+		t.Errorf("want exit code %d with changes pending, got %d", exitCodeChangesPending, exitCode)
+	}
+
+	// Changes are applied, nothing is pending
+	exitCode = 0
+	_, err = executeCommand(rootCmd, "sync", "--config", configFile, "--detailed-exitcode", "--doit", "--no-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != 0 {
+		t.Errorf("want exit code 0 after changes were applied, got %d", exitCode)
+	}
+}
+
+func Test_getSonarCheckID_resolveFromConfig(t *testing.T) {
+	originalCache := cachedSonarHTTPChecks
+	originalConfigured := configuredSonarHTTPChecks
+	defer func() {
+		cachedSonarHTTPChecks = originalCache
+		configuredSonarHTTPChecks = originalConfigured
+		resolveFromConfig = false
+	}()
+	cachedSonarHTTPChecks = []*SonarHTTPCheck{{ID: 1, Name: "existing", Host: "1.1.1.1"}}
+	configuredSonarHTTPChecks = []*ExpectedSonarHTTPCheck{
+		{SonarHTTPCheck: SonarHTTPCheck{Name: "planned", Host: "2.2.2.2"}},
+	}
+
+	_, _, err := getSonarCheckID("@sonar,http:planned")
+	if err == nil {
+		t.Error("expected error, got nil")
+		return
+	}
+
+	resolveFromConfig = true
+	id, host, err := getSonarCheckID("@sonar,http:planned")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if id != 0 || host != "2.2.2.2" {
+		t.Errorf("want 0 and %q, got %d and %q", "2.2.2.2", id, host)
+	}
+}
+
+func Test_getDriftedPlans(t *testing.T) {
+	plans := []*SyncPlan{
+		{Title: "in sync", Entries: []*PlanEntry{{Action: ActionOK, ResourceID: "a"}}},
+		{Title: "drifted", Entries: []*PlanEntry{
+			{Action: ActionOK, ResourceID: "b"},
+			{Action: ActionUpate, ResourceID: "c"},
+			{Action: ActionDelete, ResourceID: "d"},
+		}},
+	}
+	drifted := getDriftedPlans(plans)
+	if len(drifted) != 1 || drifted[0].Title != "drifted" {
+		t.Errorf("unexpected drifted plans: %+v", drifted)
+		return
+	}
+	if len(drifted[0].Entries) != 2 || drifted[0].Entries[0].ResourceID != "c" || drifted[0].Entries[1].ResourceID != "d" {
+		t.Errorf("unexpected drifted entries: %+v", drifted[0].Entries)
+	}
+}
@@ -40,6 +40,13 @@ type IActiveResource interface {
 	SyncResourceDelete(int) error
 }
 
+// IDependentResource is implemented by expected resources which reference
+// other resources. References resolved from the configuration (see
+// resolveFromConfig) point to resources which don't exist in Constellix yet
+type IDependentResource interface {
+	GetPendingReferences() []string
+}
+
 // ResourceMatcher implements resources to compare
 type ResourceMatcher interface {
 	GetResourceID() string
@@ -47,9 +54,9 @@ type ResourceMatcher interface {
 
 // FieldDiff represents difference between expected and active resource
 type FieldDiff struct {
-	FieldName string
-	OldValue  string
-	NewValue  string
+	FieldName string `json:"field" yaml:"field"`
+	OldValue  string `json:"old" yaml:"old"`
+	NewValue  string `json:"new" yaml:"new"`
 }
 
 // Return human readable string representation of the FieldDiff
@@ -88,7 +95,7 @@ func Compare(expected IExpectedResource, active IActiveResource) (ResourceAction
 			fieldExpected := expectedValue.FieldByName(structFieldName)
 			fieldActive := activeValue.FieldByName(structFieldName)
 			// Compare field values
-			if !reflect.DeepEqual(fieldExpected.Interface(), fieldActive.Interface()) {
+			if !isEqualValue(fieldExpected, fieldActive) {
 				action = ActionUpate
 				diffs = append(diffs, &FieldDiff{
 					FieldName: structFieldName,
@@ -109,6 +116,22 @@ func Compare(expected IExpectedResource, active IActiveResource) (ResourceAction
 	return "", make([]*FieldDiff, 0), fmt.Errorf("unexpected action %q", action)
 }
 
+// emptyEqualsNil is set while a backup is restored. Backups keep empty slices
+// and maps, which Constellix API returns as null, so they are equal to nil
+var emptyEqualsNil bool
+
+// isEqualValue compares field values. Nil and empty slices or maps are equal
+// only with emptyEqualsNil
+func isEqualValue(expected, active reflect.Value) bool {
+	switch expected.Kind() {
+	case reflect.Slice, reflect.Map:
+		if emptyEqualsNil && expected.Len() == 0 && active.Len() == 0 {
+			return true
+		}
+	}
+	return reflect.DeepEqual(expected.Interface(), active.Interface())
+}
+
 func toResourceMatcher(collection interface{}) []ResourceMatcher {
 	v := reflect.ValueOf(collection)
 	// No check here, just panic!
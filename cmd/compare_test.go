@@ -80,6 +80,58 @@ checkSites: [1,2]
 	}
 }
 
+func Test_Compare_http_checks_empty_slice_neq_nil(t *testing.T) {
+	expectedStr := `
+name: prod
+notificationGroups: []
+`
+	var expected ExpectedSonarHTTPCheck
+	err := yaml.Unmarshal([]byte(expectedStr), &expected)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	active := SonarHTTPCheck{Name: "prod"}
+	action, _, err := Compare(&expected, &active)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if action != ActionUpate {
+		t.Errorf("expected action '%v', got '%v'", ActionUpate, action)
+		return
+	}
+}
+
+func Test_Compare_http_checks_empty_slice_eq_nil_restore(t *testing.T) {
+	emptyEqualsNil = true
+	defer func() {
+		emptyEqualsNil = false
+	}()
+	expectedStr := `
+name: prod
+notificationGroups: []
+`
+	var expected ExpectedSonarHTTPCheck
+	err := yaml.Unmarshal([]byte(expectedStr), &expected)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	active := SonarHTTPCheck{Name: "prod"}
+	action, _, err := Compare(&expected, &active)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if action != ActionOK {
+		t.Errorf("expected action '%v', got '%v'", ActionOK, action)
+		return
+	}
+}
+
 func Test_Compare_http_checks_diff_slice_neq_order(t *testing.T) {
 	expectedStr := `
 name: prod
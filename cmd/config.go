@@ -11,6 +11,8 @@ type MainConfig struct {
 	Constellix struct {
 		Sonar                   SonarConfig         `yaml:"sonar"`
 		GeoProximityConfigFiles []string            `yaml:"geoproximity"`
+		PoolsConfigFiles        []string            `yaml:"pools"`
+		IPFiltersConfigFiles    []string            `yaml:"ipfilters"`
 		DNS                     map[string][]string `yaml:"dns"`
 	} `yaml:"constellix"`
 }
@@ -18,13 +20,21 @@ type MainConfig struct {
 type SonarConfig struct {
 	HTTPChecksConfigFiles []string `yaml:"http_checks"`
 	TCPChecksConfigFiles  []string `yaml:"tcp_checks"`
+	ICMPChecksConfigFiles []string `yaml:"icmp_checks"`
+	DNSChecksConfigFiles  []string `yaml:"dns_checks"`
+	SSLChecksConfigFiles  []string `yaml:"ssl_checks"`
 }
 
 type Config struct {
 	SonarHTTPChecks []*ExpectedSonarHTTPCheck
 	SonarTCPChecks  []*ExpectedSonarTCPCheck
+	SonarICMPChecks []*ExpectedSonarICMPCheck
+	SonarDNSChecks  []*ExpectedSonarDNSCheck
+	SonarSSLChecks  []*ExpectedSonarSSLCheck
 	DNS             map[string][]*ExpectedDNSRecord
 	GeoProximities  []*ExpectedGeoProximity
+	Pools           []*ExpectedPool
+	IPFilters       []*ExpectedIPFilter
 }
 
 func getConfig(configFile string) (*Config, error) {
@@ -86,20 +96,66 @@ func getConfig(configFile string) (*Config, error) {
 		}
 	}
 
-	// DNS
-	config.DNS = make(map[string][]*ExpectedDNSRecord)
-	for domainName, cfs := range mainConfig.Constellix.DNS {
-		dataB, err = readConfigs(cfs, filepath.Dir(configFile))
+	dataB, err = readConfigs(mainConfig.Constellix.Sonar.ICMPChecksConfigFiles, filepath.Dir(configFile))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range dataB {
+		var icmpChecks []*ExpectedSonarICMPCheck
+		err = yaml.Unmarshal(item, &icmpChecks)
 		if err != nil {
 			return nil, err
 		}
-		for _, dataItem := range dataB {
-			var records []*ExpectedDNSRecord
-			err = yaml.Unmarshal(dataItem, &records)
-			if err != nil {
-				return nil, err
+		if len(icmpChecks) > 0 {
+			config.SonarICMPChecks = append(config.SonarICMPChecks, icmpChecks...)
+			for _, check := range icmpChecks {
+				err = check.Validate()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	dataB, err = readConfigs(mainConfig.Constellix.Sonar.DNSChecksConfigFiles, filepath.Dir(configFile))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range dataB {
+		var dnsChecks []*ExpectedSonarDNSCheck
+		err = yaml.Unmarshal(item, &dnsChecks)
+		if err != nil {
+			return nil, err
+		}
+		if len(dnsChecks) > 0 {
+			config.SonarDNSChecks = append(config.SonarDNSChecks, dnsChecks...)
+			for _, check := range dnsChecks {
+				err = check.Validate()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	dataB, err = readConfigs(mainConfig.Constellix.Sonar.SSLChecksConfigFiles, filepath.Dir(configFile))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range dataB {
+		var sslChecks []*ExpectedSonarSSLCheck
+		err = yaml.Unmarshal(item, &sslChecks)
+		if err != nil {
+			return nil, err
+		}
+		if len(sslChecks) > 0 {
+			config.SonarSSLChecks = append(config.SonarSSLChecks, sslChecks...)
+			for _, check := range sslChecks {
+				err = check.Validate()
+				if err != nil {
+					return nil, err
+				}
 			}
-			config.DNS[domainName] = append(config.DNS[domainName], records...)
 		}
 	}
 
@@ -124,6 +180,88 @@ func getConfig(configFile string) (*Config, error) {
 			}
 		}
 	}
+
+	// IP filters
+	dataB, err = readConfigs(mainConfig.Constellix.IPFiltersConfigFiles, filepath.Dir(configFile))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range dataB {
+		var filters []*ExpectedIPFilter
+		err = yaml.Unmarshal(item, &filters)
+		if err != nil {
+			return nil, err
+		}
+		if len(filters) > 0 {
+			config.IPFilters = append(config.IPFilters, filters...)
+			for _, filter := range filters {
+				err = filter.Validate()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// Pools and DNS records may reference resources which are defined in the
+	// configuration but don't exist in Constellix yet (see resolveFromConfig)
+	configuredSonarHTTPChecks = config.SonarHTTPChecks
+	configuredSonarICMPChecks = config.SonarICMPChecks
+	configuredSonarTCPChecks = config.SonarTCPChecks
+	configuredSonarDNSChecks = config.SonarDNSChecks
+	configuredSonarSSLChecks = config.SonarSSLChecks
+	configuredGeoProximities = config.GeoProximities
+	configuredIPFilters = config.IPFilters
+
+	// Pools
+	dataB, err = readConfigs(mainConfig.Constellix.PoolsConfigFiles, filepath.Dir(configFile))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range dataB {
+		var pools []*ExpectedPool
+		err = yaml.Unmarshal(item, &pools)
+		if err != nil {
+			return nil, err
+		}
+		if len(pools) > 0 {
+			config.Pools = append(config.Pools, pools...)
+			for _, pool := range pools {
+				err = pool.Validate()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	configuredPools = config.Pools
+
+	// DNS
+	config.DNS = make(map[string][]*ExpectedDNSRecord)
+	for domainName, cfs := range mainConfig.Constellix.DNS {
+		dataB, err = readConfigs(cfs, filepath.Dir(configFile))
+		if err != nil {
+			return nil, err
+		}
+		for _, dataItem := range dataB {
+			var records []*ExpectedDNSRecord
+			err = yaml.Unmarshal(dataItem, &records)
+			if err != nil {
+				return nil, err
+			}
+			config.DNS[domainName] = append(config.DNS[domainName], records...)
+			for _, record := range records {
+				err = record.resolvePTRName(domainName)
+				if err != nil {
+					return nil, err
+				}
+				err = record.Validate()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
 	return &config, nil
 }
 
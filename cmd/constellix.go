@@ -5,7 +5,10 @@ import (
 	"crypto/sha1"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -41,6 +44,103 @@ const StatusDown ResourceRuntimeStatus = "DOWN"
 // RuntimeStatus is a struct that represents the runtime status of a resource
 type RuntimeStatus struct {
 	Status ResourceRuntimeStatus `json:"status"`
+	Sites  []*SiteRuntimeStatus  `json:"checkSites,omitempty"`
+}
+
+// SiteRuntimeStatus is the runtime status of a Sonar check as seen from a
+// single check site
+type SiteRuntimeStatus struct {
+	Site         string                `json:"siteName"`
+	Status       ResourceRuntimeStatus `json:"status"`
+	LastCheck    SonarTime             `json:"lastCheck"`
+	ResponseTime float64               `json:"responseTime"`
+	StatusCode   int                   `json:"statusCode"`
+	Error        string                `json:"errorMessage"`
+}
+
+// SonarTime is a timestamp returned by Sonar API, which is either Unix time in
+// milliseconds or RFC 3339 string
+type SonarTime struct {
+	time.Time
+}
+
+func (t *SonarTime) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	t.Time, err = parseSonarTime(v)
+	return err
+}
+
+func (t SonarTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.Format(time.RFC3339))
+}
+
+func (t SonarTime) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// parseSonarTime parses decoded JSON timestamp returned by Sonar API
+func parseSonarTime(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case float64:
+		return time.UnixMilli(int64(v)).UTC(), nil
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unable to parse time: %s", err)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("unknown time format: %v", v)
+}
+
+// getSonarCheckStatus returns status of the Sonar check using runtime endpoint
+func getSonarCheckStatus(checkType string, id int) (ResourceRuntimeStatus, error) {
+	status, err := getSonarCheckRuntime(checkType, id)
+	if err != nil {
+		return "", err
+	}
+	return status.Status, nil
+}
+
+// getSonarCheckRuntime returns status of the Sonar check with the breakdown
+// per check site using runtime endpoint
+func getSonarCheckRuntime(checkType string, id int) (*RuntimeStatus, error) {
+	status := RuntimeStatus{Status: "unknown"}
+	err := readSonarCheckRuntime(checkType, id, &status)
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// readSonarCheckRuntime decodes the response of the runtime endpoint of the
+// Sonar check to status, which can contain check type specific fields
+func readSonarCheckRuntime(checkType string, id int, status interface{}) error {
+	if logLevel > 0 {
+		logger.Printf("Retrieving status for Sonar %s Check %d...\n", strings.ToUpper(checkType), id)
+	}
+	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, checkType, strconv.Itoa(id), "status")
+	if err != nil {
+		return err
+	}
+	data, err := makeSimpleAPIRequest("GET", endpoint, nil, 200)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve Sonar %s check status: %s", strings.ToUpper(checkType), err)
+	}
+	return json.Unmarshal(data, status)
 }
 
 type DNSv4Response struct {
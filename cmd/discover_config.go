@@ -0,0 +1,420 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fields which are assigned by Constellix and can't be configured
+var serverOnlyConfigFields = []string{"id", "userId"}
+
+// writeDiscoveredResources writes discovered resources to the file or prints
+// them. With asConfig, resources are written in configuration format, which
+// can be synced back without any changes
+func writeDiscoveredResources(collection interface{}, outputFile string, asConfig bool) error {
+	if !asConfig {
+		return writeDiscoveryResult(collection, outputFile)
+	}
+	nodes, err := newConfigResolver().configNodes(collection)
+	if err != nil {
+		return err
+	}
+	return writeDiscoveryResult(nodes, outputFile)
+}
+
+// configResolver converts discovered resources to configuration. IDs of
+// referenced resources are resolved back to references, e.g.
+// @geoproximity:<name>. Referenced resources are retrieved once, when needed
+type configResolver struct {
+	// keepZeroValues keeps fields with default (zero) value, so syncing the
+	// configuration back resets them too, e.g. when a backup is restored
+	keepZeroValues bool
+	geoProximities []*GeoProximity
+	ipFilters      []*IPFilter
+	pools          []*Pool
+	// sonarChecks are active Sonar checks by check type
+	sonarChecks map[string][]*sonarCheckReference
+}
+
+// sonarCheckTypes are types of Sonar checks which can be referenced
+var sonarCheckTypes = []string{"http", "tcp", "icmp", "dns", "ssl"}
+
+func newConfigResolver() *configResolver {
+	return &configResolver{}
+}
+
+// configNodes converts every resource of the collection to configuration
+func (r *configResolver) configNodes(collection interface{}) ([]*yaml.Node, error) {
+	v := reflect.ValueOf(collection)
+	nodes := make([]*yaml.Node, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		// Apex NS records are managed by Constellix and can't be configured
+		if record, ok := v.Index(i).Interface().(*DNSRecord); ok && record.isApexNS() {
+			continue
+		}
+		node, err := r.configNode(v.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// configNode converts the resource to configuration. Mandatory fields and
+// fields whose zero value differs from the API default (enabled) are always
+// kept, other fields are omitted when they have default (zero) value
+func (r *configResolver) configNode(resource interface{}) (*yaml.Node, error) {
+	switch v := resource.(type) {
+	case *SonarHTTPCheck:
+		return r.newConfigNode(v, "name", "host", "ipVersion", "port", "protocolType", "interval", "checkSites")
+	case *SonarTCPCheck:
+		return r.newConfigNode(v, "name", "host", "ipVersion", "port", "interval", "checkSites")
+	case *SonarICMPCheck:
+		return r.newConfigNode(v, "name", "host", "ipVersion", "interval", "checkSites")
+	case *SonarDNSCheck:
+		return r.newConfigNode(v, "name", "fqdn", "resolver", "resolverIPVersion", "recordType", "interval", "checkSites")
+	case *SonarSSLCheck:
+		return r.newConfigNode(v, "name", "host", "ipVersion", "port", "daysBeforeExpiry", "interval", "checkSites")
+	case *GeoProximity:
+		return r.newConfigNode(v, "name", "longitude", "latitude")
+	case *IPFilter:
+		return r.newConfigNode(v, "name")
+	case *Pool:
+		return r.poolConfigNode(v)
+	case *DNSRecord:
+		return r.dnsRecordConfigNode(v)
+	}
+	return nil, fmt.Errorf("unable to convert %T to configuration", resource)
+}
+
+func (r *configResolver) poolConfigNode(pool *Pool) (*yaml.Node, error) {
+	node, err := r.newConfigNode(pool, "name", "type", "return", "minimumFailover", "enabled", "values")
+	if err != nil {
+		return nil, err
+	}
+	// The value of a pool is kept when the Sonar check is referenced
+	err = r.resolveSonarCheckRefs(getConfigField(node, "values"), false)
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (r *configResolver) dnsRecordConfigNode(record *DNSRecord) (*yaml.Node, error) {
+	// Name, mode and region are kept to make the record easy to recognize.
+	// Records are created enabled unless enabled is set
+	node, err := r.newConfigNode(record, "name", "type", "mode", "region", "enabled", "value")
+	if err != nil {
+		return nil, err
+	}
+
+	if id := toInt(record.GeoProximity); id != 0 {
+		err = r.loadGeoProximities()
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(r.geoProximities))
+		ids := make([]int, len(r.geoProximities))
+		for i, p := range r.geoProximities {
+			names[i], ids[i] = p.Name, p.ID
+		}
+		if i, ok := findReference(id, names, ids); ok {
+			err = setConfigField(node, "geoproximity", "@geoproximity:"+names[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if id := toInt(record.IPFilter); id != 0 {
+		err = r.loadIPFilters()
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(r.ipFilters))
+		ids := make([]int, len(r.ipFilters))
+		for i, f := range r.ipFilters {
+			names[i], ids[i] = f.Name, f.ID
+		}
+		if i, ok := findReference(id, names, ids); ok {
+			err = setConfigField(node, "ipfilter", "@ipfilter:"+names[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// A referenced Sonar check replaces the value of failover records with
+	// the host of the check
+	switch v := record.Value.(type) {
+	case []int:
+		err = r.loadPools()
+		if err != nil {
+			return nil, err
+		}
+		names := []string{}
+		ids := []int{}
+		for _, p := range r.pools {
+			if p.Type == record.Type {
+				names = append(names, p.Name)
+				ids = append(ids, p.ID)
+			}
+		}
+		pools := make([]interface{}, len(v))
+		for i, id := range v {
+			pools[i] = id
+			if j, ok := findReference(id, names, ids); ok {
+				pools[i] = "@pool:" + names[j]
+			}
+		}
+		err = setConfigField(node, "value", pools)
+	case *DNSFailoverValue:
+		err = r.resolveSonarCheckRefs(getConfigField(getConfigField(node, "value"), "values"), true)
+	case []*DNSFailoverItemValue:
+		err = r.resolveSonarCheckRefs(getConfigField(node, "value"), true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// resolveSonarCheckRefs replaces IDs of Sonar checks in the list of values with
+// references. If the reference replaces the value, the value must be the host
+// of the check. DNS checks have no host, so the value is kept
+func (r *configResolver) resolveSonarCheckRefs(values *yaml.Node, replacesValue bool) error {
+	if values == nil || values.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, item := range values.Content {
+		checkID := getConfigField(item, "sonarCheckId")
+		if checkID == nil {
+			continue
+		}
+		id, err := strconv.Atoi(checkID.Value)
+		if err != nil || id == 0 {
+			continue
+		}
+		err = r.loadSonarChecks()
+		if err != nil {
+			return err
+		}
+
+		ref, host, ok := r.sonarCheckRef(id)
+		if !ok {
+			continue
+		}
+		if value := getConfigField(item, "value"); replacesValue && host != "" && (value == nil || value.Value != host) {
+			continue
+		}
+		err = setConfigField(item, "sonarCheckId", ref)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sonarCheckRef returns the reference to the Sonar check with the ID and the
+// host of the check
+func (r *configResolver) sonarCheckRef(id int) (string, string, bool) {
+	refs := []string{}
+	hosts := []string{}
+	matches := 0
+	for _, checkType := range sonarCheckTypes {
+		checks := r.sonarChecks[checkType]
+		names := make([]string, len(checks))
+		ids := make([]int, len(checks))
+		for i, check := range checks {
+			names[i], ids[i] = check.Name, check.ID
+			if check.ID == id {
+				matches++
+			}
+		}
+		if i, ok := findReference(id, names, ids); ok {
+			refs = append(refs, "@sonar,"+checkType+":"+names[i])
+			hosts = append(hosts, checks[i].Host)
+		}
+	}
+	// Check types are not part of the ID, so the check must be unique.
+	// Names with colons can't be parsed back from a reference
+	if matches != 1 || len(refs) != 1 || strings.Count(refs[0], ":") != 1 {
+		return "", "", false
+	}
+	return refs[0], hosts[0], true
+}
+
+func (r *configResolver) loadGeoProximities() error {
+	if r.geoProximities != nil {
+		return nil
+	}
+	proximities, err := GetGeoProximities()
+	if err != nil {
+		return err
+	}
+	r.geoProximities = proximities
+	return nil
+}
+
+func (r *configResolver) loadIPFilters() error {
+	if r.ipFilters != nil {
+		return nil
+	}
+	filters, err := GetIPFilters()
+	if err != nil {
+		return err
+	}
+	r.ipFilters = filters
+	return nil
+}
+
+func (r *configResolver) loadPools() error {
+	if r.pools != nil {
+		return nil
+	}
+	pools, err := GetPools()
+	if err != nil {
+		return err
+	}
+	r.pools = pools
+	return nil
+}
+
+func (r *configResolver) loadSonarChecks() error {
+	if r.sonarChecks != nil {
+		return nil
+	}
+	sonarChecks := make(map[string][]*sonarCheckReference, len(sonarCheckTypes))
+	for _, checkType := range sonarCheckTypes {
+		checks, _, err := getSonarCheckReferences(checkType)
+		if err != nil {
+			return err
+		}
+		sonarChecks[checkType] = checks
+	}
+	r.sonarChecks = sonarChecks
+	return nil
+}
+
+// findReference returns the index of the resource with the ID. References are
+// resolved to the first resource with the name, so the resource is found only
+// if it is the first one with its name
+func findReference(id int, names []string, ids []int) (int, bool) {
+	for i := range ids {
+		if ids[i] != id {
+			continue
+		}
+		// Names are trimmed when references are resolved
+		if names[i] == "" || names[i] != strings.TrimSpace(names[i]) {
+			return 0, false
+		}
+		for j := range names {
+			if names[j] == names[i] {
+				return i, j == i
+			}
+		}
+	}
+	return 0, false
+}
+
+// newConfigNode encodes the resource as a YAML mapping without server-only
+// fields. Fields with zero value are omitted unless they are listed in keep or
+// keepZeroValues is set
+func (r *configResolver) newConfigNode(resource interface{}, keep ...string) (*yaml.Node, error) {
+	var node yaml.Node
+	err := node.Encode(resource)
+	if err != nil {
+		return nil, err
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("unable to convert %T to configuration, expected a mapping", resource)
+	}
+	content := make([]*yaml.Node, 0, len(node.Content))
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if isServerOnlyConfigField(key.Value) {
+			continue
+		}
+		if !r.keepZeroValues && !isKeptConfigField(key.Value, keep) && isZeroConfigValue(value) {
+			continue
+		}
+		content = append(content, key, value)
+	}
+	node.Content = content
+	return &node, nil
+}
+
+func isServerOnlyConfigField(key string) bool {
+	for _, field := range serverOnlyConfigFields {
+		if field == key {
+			return true
+		}
+	}
+	return false
+}
+
+func isKeptConfigField(key string, keep []string) bool {
+	for _, field := range keep {
+		if field == key {
+			return true
+		}
+	}
+	return false
+}
+
+// isZeroConfigValue returns true if the node is decoded to zero value
+func isZeroConfigValue(node *yaml.Node) bool {
+	switch node.Kind {
+	case yaml.SequenceNode, yaml.MappingNode:
+		return len(node.Content) == 0
+	case yaml.ScalarNode:
+		switch node.Tag {
+		case "!!null":
+			return true
+		case "!!str":
+			return node.Value == ""
+		case "!!bool":
+			return node.Value == "false"
+		case "!!int", "!!float":
+			f, err := strconv.ParseFloat(node.Value, 64)
+			return err == nil && f == 0
+		}
+	}
+	return false
+}
+
+// getConfigField returns the value of the field of the mapping node
+func getConfigField(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setConfigField replaces the value of the field of the mapping node or adds
+// the field
+func setConfigField(node *yaml.Node, key string, value interface{}) error {
+	var valueNode yaml.Node
+	err := valueNode.Encode(value)
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = &valueNode
+			return nil
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &valueNode)
+	return nil
+}
@@ -0,0 +1,311 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func Test_writeDiscoveredResources_as_config(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/http":
+			w.Write([]byte(`[{"id":1,"name":"web","host":"192.0.2.1","port":443,"protocolType":"HTTPS",` +
+				`"ipVersion":"IPV4","interval":"ONEMINUTE","checkSites":[1,2],"userId":42,"searchString":""}]`))
+		case "/icmp":
+			w.Write([]byte(`[{"id":2,"name":"ping","host":"192.0.2.9","ipVersion":"IPV4","interval":"ONEMINUTE","checkSites":[1]}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+	originalSonarRESTAPIBaseURL := sonarRESTAPIBaseURL
+	originalGetGeoProximities := GetGeoProximities
+	originalGetIPFilters := GetIPFilters
+	originalGetPools := GetPools
+	resetCache()
+	defer func() {
+		sonarRESTAPIBaseURL = originalSonarRESTAPIBaseURL
+		GetGeoProximities = originalGetGeoProximities
+		GetIPFilters = originalGetIPFilters
+		GetPools = originalGetPools
+		resetCache()
+	}()
+	sonarRESTAPIBaseURL = ts.URL
+	GetGeoProximities = func() ([]*GeoProximity, error) {
+		return []*GeoProximity{{ID: 5, Name: "amsterdam", Latitude: 52.37, Longitude: 4.89}}, nil
+	}
+	GetIPFilters = func() ([]*IPFilter, error) {
+		return []*IPFilter{{ID: 3, Name: "office"}}, nil
+	}
+	pools := []*Pool{
+		{ID: 7, Name: "web", Type: "A", Return: 1, Enabled: true, Values: []*PoolValue{
+			{Value: "192.0.2.10", Weight: 1, Enabled: true, SonarCheckID: 1},
+		}},
+		{ID: 8, Name: "web", Type: "AAAA", Return: 1, Enabled: true, Values: []*PoolValue{
+			{Value: "2001:db8::1", Weight: 1, Enabled: true},
+		}},
+	}
+	GetPools = func() ([]*Pool, error) {
+		return pools, nil
+	}
+
+	data := `[
+{"id":100,"name":"www","type":"A","ttl":60,"mode":"failover","region":"default","enabled":true,
+ "geoproximity":{"id":5},"ipfilter":{"id":3},
+ "value":{"mode":"normal","enabled":true,"values":[
+  {"value":"192.0.2.1","order":1,"sonarCheckId":1,"enabled":true},
+  {"value":"192.0.2.2","order":2,"sonarCheckId":2,"enabled":false}]}},
+{"id":101,"name":"pool","type":"A","ttl":60,"mode":"pools","region":"europe","enabled":true,"value":[7,99]},
+{"id":102,"name":"","type":"MX","ttl":300,"mode":"standard","region":"default","enabled":false,"notes":"",
+ "value":[{"server":"mail.example.com.","priority":0,"enabled":true}]}
+]`
+	var records []*DNSRecord
+	err := json.Unmarshal([]byte(data), &records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks, err := GetSonarHTTPChecks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for file, collection := range map[string]interface{}{
+		"records.yaml": records, "pools.yaml": pools, "checks.yaml": checks,
+	} {
+		err = writeDiscoveredResources(collection, filepath.Join(dir, file), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recordsData, err := os.ReadFile(filepath.Join(dir, "records.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"geoproximity: '@geoproximity:amsterdam'",
+		"ipfilter: '@ipfilter:office'",
+		"sonarCheckId: '@sonar,http:web'",
+		// Value of the failover item differs from the host of the check
+		"sonarCheckId: 2",
+		"- '@pool:web'",
+		"- 99",
+		"priority: 0",
+		// Records are created enabled unless it is set
+		"enabled: false",
+	} {
+		if !strings.Contains(string(recordsData), expected) {
+			t.Errorf("expected %q in:\n%s", expected, recordsData)
+		}
+	}
+	for _, unexpected := range []string{"id: 10", "notes:"} {
+		if strings.Contains(string(recordsData), unexpected) {
+			t.Errorf("unexpected %q in:\n%s", unexpected, recordsData)
+		}
+	}
+
+	var expectedRecords []*ExpectedDNSRecord
+	err = yaml.Unmarshal(recordsData, &expectedRecords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksData, err := os.ReadFile(filepath.Join(dir, "checks.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(checksData), "userId") || strings.Contains(string(checksData), "searchString") {
+		t.Errorf("unexpected server-only or default fields in:\n%s", checksData)
+	}
+	var expectedChecks []*ExpectedSonarHTTPCheck
+	err = yaml.Unmarshal(checksData, &expectedChecks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poolsData, err := os.ReadFile(filepath.Join(dir, "pools.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(poolsData), "sonarCheckId: '@sonar,http:web'") {
+		t.Errorf("expected reference to the Sonar check in:\n%s", poolsData)
+	}
+	var expectedPools []*ExpectedPool
+	err = yaml.Unmarshal(poolsData, &expectedPools)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Synced back, the configuration must not change anything
+	expected := append(append(toResourceMatcher(expectedRecords), toResourceMatcher(expectedChecks)...), toResourceMatcher(expectedPools)...)
+	active := append(append(toResourceMatcher(records), toResourceMatcher(checks)...), toResourceMatcher(pools)...)
+	if len(expected) != len(active) {
+		t.Fatalf("expected %d resources, got %d", len(active), len(expected))
+	}
+	for i, item := range expected {
+		ex := item.(IExpectedResource)
+		err = item.(interface{ Validate() error }).Validate()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ex.GetResourceID() != active[i].GetResourceID() {
+			t.Errorf("expected %s, got %s", active[i].GetResourceID(), ex.GetResourceID())
+			continue
+		}
+		action, diffs, err := Compare(ex, active[i].(IActiveResource))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if action != ActionOK {
+			t.Errorf("%s: expected no changes, got %s %+v", ex.GetResourceID(), action, diffs)
+		}
+	}
+}
+
+func Test_writeDiscoveredResources_as_config_sonar_check_types(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tcp":
+			w.Write([]byte(`[{"id":3,"name":"smtp","host":"192.0.2.3","port":25}]`))
+		case "/dns":
+			w.Write([]byte(`[{"id":4,"name":"resolve","fqdn":"example.com","resolver":"192.0.2.53"}]`))
+		case "/ssl":
+			w.Write([]byte(`[{"id":5,"name":"cert","host":"192.0.2.5","port":443}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+	originalSonarRESTAPIBaseURL := sonarRESTAPIBaseURL
+	resetCache()
+	defer func() {
+		sonarRESTAPIBaseURL = originalSonarRESTAPIBaseURL
+		resetCache()
+	}()
+	sonarRESTAPIBaseURL = ts.URL
+
+	data := `[
+{"id":100,"name":"www","type":"A","ttl":60,"mode":"failover","region":"default","enabled":true,
+ "value":{"mode":"normal","enabled":true,"values":[
+  {"value":"192.0.2.3","order":1,"sonarCheckId":3,"enabled":true},
+  {"value":"192.0.2.4","order":2,"sonarCheckId":4,"enabled":true},
+  {"value":"192.0.2.5","order":3,"sonarCheckId":5,"enabled":true}]}}
+]`
+	var records []*DNSRecord
+	err := json.Unmarshal([]byte(data), &records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "records.yaml")
+	err = writeDiscoveredResources(records, file, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordsData, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"sonarCheckId: '@sonar,tcp:smtp'",
+		"sonarCheckId: '@sonar,dns:resolve'",
+		"sonarCheckId: '@sonar,ssl:cert'",
+	} {
+		if !strings.Contains(string(recordsData), expected) {
+			t.Errorf("expected %q in:\n%s", expected, recordsData)
+		}
+	}
+
+	// References resolve back to the same checks
+	var expectedRecords []*ExpectedDNSRecord
+	err = yaml.Unmarshal(recordsData, &expectedRecords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	action, diffs, err := Compare(expectedRecords[0], records[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action != ActionOK {
+		t.Errorf("expected no changes, got %s %+v", action, diffs)
+	}
+}
+
+func Test_configNodes_disabled_record_round_trip(t *testing.T) {
+	record := &DNSRecord{
+		Name: "www", Type: "A", TTL: 60, Mode: "standard", Region: "default", Enabled: false,
+		Value: []*DNSStandardItemValue{{Value: "192.0.2.1", Enabled: true}},
+	}
+	nodes, err := newConfigResolver().configNodes([]*DNSRecord{record})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := yaml.Marshal(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected []*ExpectedDNSRecord
+	err = yaml.Unmarshal(data, &expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Synced back, the configuration keeps the record disabled
+	action, _, err := Compare(expected[0], record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action != ActionOK {
+		t.Errorf("want %s, got %s", ActionOK, action)
+	}
+	enabled := *record
+	enabled.Enabled = true
+	action, diffs, err := Compare(expected[0], &enabled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action != ActionUpate || len(diffs) != 1 || diffs[0].FieldName != "Enabled" {
+		t.Errorf("want the record to be disabled, got %s %+v in:\n%s", action, diffs, data)
+	}
+}
+
+func Test_configNodes_skip_apex_NS(t *testing.T) {
+	records := []*DNSRecord{
+		{Name: "", Type: "NS", Mode: "standard", Enabled: true},
+		{Name: "sub", Type: "NS", Mode: "standard", Enabled: true},
+	}
+	nodes, err := newConfigResolver().configNodes(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || getConfigField(nodes[0], "name").Value != "sub" {
+		t.Errorf("want only the delegation of sub, got %d records", len(nodes))
+	}
+}
+
+func Test_findReference(t *testing.T) {
+	names := []string{"a", "b", "a", " c"}
+	ids := []int{1, 2, 3, 4}
+	for _, c := range []struct {
+		id       int
+		expected bool
+	}{
+		{1, true},
+		{2, true},
+		// Reference to "a" is resolved to the resource with ID 1
+		{3, false},
+		// Names are trimmed when references are resolved
+		{4, false},
+		{5, false},
+	} {
+		if _, ok := findReference(c.id, names, ids); ok != c.expected {
+			t.Errorf("%d: expected %v, got %v", c.id, c.expected, ok)
+		}
+	}
+}
@@ -6,21 +6,33 @@ import (
 	"net/url"
 )
 
-// SOA is ignored for now
 type DNSDomain struct {
-	ID               int         `json:"id"`
-	Name             string      `json:"name"`
-	Note             string      `json:"note"`
-	Status           string      `json:"status"`
-	GeoIPEnabled     bool        `json:"geoip"`
-	GTDEnabled       bool        `json:"gtd"`
-	Nameservers      []string    `json:"nameservers"`
-	Tags             []string    `json:"tags"`
-	Template         int         `json:"template"`
-	VanityNameserver interface{} `json:"vanityNameserver"`
-	Contacts         []int       `json:"contacts"`
-	CreatedAt        string      `json:"createdAt"`
-	UpdatedAt        string      `json:"updatedAt"`
+	ID               int           `json:"id"`
+	Name             string        `json:"name"`
+	Note             string        `json:"note"`
+	Status           string        `json:"status"`
+	GeoIPEnabled     bool          `json:"geoip"`
+	GTDEnabled       bool          `json:"gtd"`
+	Nameservers      []string      `json:"nameservers"`
+	SOA              *DNSDomainSOA `json:"soa"`
+	Tags             []string      `json:"tags"`
+	Template         int           `json:"template"`
+	VanityNameserver interface{}   `json:"vanityNameserver"`
+	Contacts         []int         `json:"contacts"`
+	CreatedAt        string        `json:"createdAt"`
+	UpdatedAt        string        `json:"updatedAt"`
+}
+
+// DNSDomainSOA contains SOA record fields of the domain
+type DNSDomainSOA struct {
+	PrimaryNameserver string `json:"primaryNameserver"`
+	Email             string `json:"email"`
+	TTL               int    `json:"ttl"`
+	Serial            int    `json:"serial"`
+	Refresh           int    `json:"refresh"`
+	Retry             int    `json:"retry"`
+	Expire            int    `json:"expire"`
+	NegativeCache     int    `json:"negativeCache"`
 }
 
 // GetDNSDomains returns active DNS domains in Constellix
@@ -34,7 +46,7 @@ func GetDNSDomains() ([]*DNSDomain, error) {
 	}
 	data, err := makev4APIRequest("GET", endpoint, nil, 200)
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve DNS domains list: %s", err)
+		return nil, fmt.Errorf("unable to retrieve DNS domains list: %w", err)
 	}
 	var domains []*DNSDomain
 	for _, item := range data {
@@ -47,3 +59,17 @@ func GetDNSDomains() ([]*DNSDomain, error) {
 	}
 	return domains, nil
 }
+
+// getDNSDomain returns the domain with the name
+func getDNSDomain(name string) (*DNSDomain, error) {
+	domains, err := GetDNSDomains()
+	if err != nil {
+		return nil, err
+	}
+	for _, domain := range domains {
+		if domain.Name == name {
+			return domain, nil
+		}
+	}
+	return nil, fmt.Errorf("domain %s not found", name)
+}
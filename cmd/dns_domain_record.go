@@ -91,11 +91,16 @@ func (ac *DNSRecord) SyncResourceDelete(constellixID int) error {
 			details += string(item)
 		}
 		logger.Println("  unexpected response. Details: " + details)
-		return fmt.Errorf("unable to delete DNS record: %s", err)
+		return fmt.Errorf("unable to delete DNS record: %w", err)
 	}
 	return nil
 }
 
+// isApexNS returns true if the record is one of the domain's own name servers
+func (ac *DNSRecord) isApexNS() bool {
+	return ac.Type == "NS" && ac.Name == ""
+}
+
 type ExpectedDNSRecord struct {
 	// Mapping of defined fields from parsed data to struct Field Names
 	definedFieldsMap map[string]string
@@ -103,6 +108,10 @@ type ExpectedDNSRecord struct {
 	immutableFields []string
 	// List of mandatory fields which must be defined, used for validation
 	mandatoryFields []string
+	// IP address of a PTR record, the name is computed from it (see resolvePTRName)
+	ip string
+	// References to resources which don't exist in Constellix yet
+	pendingReferences []string
 	DNSRecord
 }
 
@@ -118,6 +127,7 @@ func (ex *ExpectedDNSRecord) UnmarshalYAML(value *yaml.Node) error {
 		return err
 	}
 
+	takePendingReferences()
 	err = populateDNSRecordValue(&s)
 	if err != nil {
 		return err
@@ -131,6 +141,7 @@ func (ex *ExpectedDNSRecord) UnmarshalYAML(value *yaml.Node) error {
 		return err
 	}
 	ex.DNSRecord = s
+	ex.pendingReferences = takePendingReferences()
 
 	// Save specified fields
 	dm := make(map[string]interface{})
@@ -146,6 +157,27 @@ func (ex *ExpectedDNSRecord) UnmarshalYAML(value *yaml.Node) error {
 		i++
 	}
 	ex.definedFieldsMap = getFieldNamesMap(&ex.DNSRecord, "yaml", definedFields...)
+	if ip, ok := dm["ip"]; ok {
+		ex.ip = fmt.Sprint(ip)
+	}
+	return nil
+}
+
+// Validate performs simple validation of user provided data
+func (ex *ExpectedDNSRecord) Validate() error {
+	// Validate that all mandatory fields are present
+	for _, f := range ex.mandatoryFields {
+		if !slices.Contains(maps.Keys(ex.definedFieldsMap), f) {
+			return fmt.Errorf("%s: mandatory field %q is not defined", ex.GetResourceID(), f)
+		}
+	}
+	if ex.isApexNS() {
+		return fmt.Errorf("%s: apex NS records are managed by Constellix", ex.GetResourceID())
+	}
+	err := validateDNSRecordValue(&ex.DNSRecord)
+	if err != nil {
+		return fmt.Errorf("%s: %s", ex.GetResourceID(), err)
+	}
 	return nil
 }
 
@@ -165,6 +197,12 @@ func (ex *ExpectedDNSRecord) GetImmutableStructFields() []string {
 	return imf
 }
 
+// GetPendingReferences returns references to resources which don't exist in
+// Constellix yet
+func (ex *ExpectedDNSRecord) GetPendingReferences() []string {
+	return ex.pendingReferences
+}
+
 func (ex *ExpectedDNSRecord) GetResource() interface{} {
 	return ex.DNSRecord
 }
@@ -203,7 +241,7 @@ func (ex *ExpectedDNSRecord) SyncResourceUpdate(constellixID int) error {
 			details += string(item)
 		}
 		logger.Println("  unexpected response. Details: " + details)
-		return fmt.Errorf("unable to update DNS record: %s", err)
+		return fmt.Errorf("unable to update DNS record: %w", err)
 	}
 	return nil
 }
@@ -229,7 +267,7 @@ func (ex *ExpectedDNSRecord) SyncResourceCreate() error {
 			details += string(item)
 		}
 		logger.Println("  unexpected response. Details: " + details)
-		return fmt.Errorf("unable to create DNS record: %s", err)
+		return fmt.Errorf("unable to create DNS record: %w", err)
 	}
 	return nil
 }
@@ -246,7 +284,7 @@ func GetDNSRecords(id int) ([]*DNSRecord, error) {
 
 	data, err := makev4APIRequest("GET", endpoint, nil, 200)
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve DNS domains list: %s", err)
+		return nil, fmt.Errorf("unable to retrieve DNS domains list: %w", err)
 	}
 
 	var records []*DNSRecord
@@ -266,3 +304,15 @@ func GetDNSRecords(id int) ([]*DNSRecord, error) {
 	}
 	return records, nil
 }
+
+// withoutApexNS returns the records without apex NS records, which are
+// managed by Constellix itself
+func withoutApexNS(records []*DNSRecord) []*DNSRecord {
+	managedRecords := make([]*DNSRecord, 0, len(records))
+	for _, item := range records {
+		if !item.isApexNS() {
+			managedRecords = append(managedRecords, item)
+		}
+	}
+	return managedRecords
+}
@@ -63,6 +63,14 @@ func getGeoproximityID(gp interface{}) (int, error) {
 				return p.ID, nil
 			}
 		}
+		if resolveFromConfig {
+			for _, p := range configuredGeoProximities {
+				if p.Name == name {
+					pendingReferences = append(pendingReferences, v)
+					return 0, nil
+				}
+			}
+		}
 		return 0, fmt.Errorf("unable to find geoproximity %s", name)
 	case int, float64:
 		return toInt(gp), nil
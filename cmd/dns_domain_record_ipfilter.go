@@ -1,9 +1,13 @@
 package cmd
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // In Constellix API, GET requests return an object, but POST and PATCH requests
-// expect an integer. yaml configuration will also have an integer.
+// expect an integer. yaml configuration will have an integer or a reference
+// `@ipfilter:<name>`.
 
 // populateDNSRecordIPFilterForJSON populates the IPFilter field from
 // the JSON response from the API.
@@ -33,11 +37,45 @@ func populateDNSRecordIPFilterForYAML(record interface{}) error {
 	if s.IPFilter == nil {
 		return nil
 	}
-	switch s.IPFilter.(type) {
+	ipFilterID, err := getIPFilterID(s.IPFilter)
+	if err != nil {
+		return err
+	}
+	s.IPFilter = ipFilterID
+	return nil
+}
+
+// getIPFilterID returns the ID of the IP filter. It supports both an integer and
+// a string `@ipfilter:Name`.
+func getIPFilterID(ipFilter interface{}) (int, error) {
+	switch v := ipFilter.(type) {
+	case string:
+		if !strings.HasPrefix(v, "@ipfilter:") {
+			return 0, fmt.Errorf("unable to parse value for ipfilter, expected @ipfilter:<name> or int")
+		}
+		name := strings.TrimPrefix(v, "@ipfilter:")
+		name = strings.TrimSpace(name)
+		filters, err := GetIPFilters()
+		if err != nil {
+			return 0, err
+		}
+		for _, f := range filters {
+			if f.Name == name {
+				return f.ID, nil
+			}
+		}
+		if resolveFromConfig {
+			for _, f := range configuredIPFilters {
+				if f.Name == name {
+					pendingReferences = append(pendingReferences, v)
+					return 0, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("unable to find ipfilter %s", name)
 	case int, float64:
-		s.IPFilter = toInt(s.IPFilter)
-		return nil
+		return toInt(ipFilter), nil
 	default:
-		return fmt.Errorf("unable to parse value for ipfilter, expected an integer")
+		return 0, fmt.Errorf("unable to parse value for ipfilter, expected @ipfilter:<name> or int")
 	}
 }
@@ -1,6 +1,12 @@
 package cmd
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
 
 func TestPopulateDNSRecordIpfilterForJSON_ValidObject(t *testing.T) {
 	record := &DNSRecord{
@@ -18,3 +24,81 @@ func TestPopulateDNSRecordIpfilterForJSON_ValidObject(t *testing.T) {
 		t.Errorf("populateDNSRecordIPFilterForJSON() = %v, want %v", record.IPFilter, 1)
 	}
 }
+
+func TestPopulateDNSRecordIpfilterForYAML_Reference(t *testing.T) {
+	// Mock the GetIPFilters function to control its behavior for testing
+	oldGetIPFilters := GetIPFilters
+	defer func() { GetIPFilters = oldGetIPFilters }()
+	GetIPFilters = func() ([]*IPFilter, error) {
+		return []*IPFilter{
+			{ID: 5, Name: "office-only"},
+		}, nil
+	}
+
+	record := &DNSRecord{IPFilter: "@ipfilter:office-only"}
+	err := populateDNSRecordIPFilterForYAML(record)
+	if err != nil {
+		t.Errorf("populateDNSRecordIPFilterForYAML() error = %v, wantErr %v", err, false)
+	}
+	if record.IPFilter != 5 {
+		t.Errorf("populateDNSRecordIPFilterForYAML() = %v, want %v", record.IPFilter, 5)
+	}
+
+	record = &DNSRecord{IPFilter: "@ipfilter:unknown"}
+	err = populateDNSRecordIPFilterForYAML(record)
+	expected := "unable to find ipfilter unknown"
+	if err == nil || err.Error() != expected {
+		t.Errorf("populateDNSRecordIPFilterForYAML() error = %v, want %q", err, expected)
+	}
+}
+
+func TestGetIPFilterID_cached(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(v4TestResponse(`[{"id":5,"name":"office-only"}]`)))
+	}))
+	defer ts.Close()
+	originalDNSRESTAPIBaseURL := dnsRESTAPIBaseURL
+	resetCache()
+	defer func() {
+		dnsRESTAPIBaseURL = originalDNSRESTAPIBaseURL
+		resetCache()
+	}()
+	dnsRESTAPIBaseURL = ts.URL
+
+	for _, filter := range []string{"@ipfilter:office-only", "@ipfilter:office-only", "@ipfilter:missing"} {
+		getIPFilterID(filter)
+	}
+	if requests != 1 {
+		t.Errorf("want IP filters to be retrieved once, got %d requests", requests)
+	}
+	resetCache()
+	got, err := getIPFilterID("@ipfilter:office-only")
+	if err != nil || got != 5 {
+		t.Errorf("getIPFilterID() = %v, %v, want %v", got, err, 5)
+	}
+	if requests != 2 {
+		t.Errorf("want IP filters to be retrieved again after resetCache, got %d requests", requests)
+	}
+}
+
+func TestExpectedIPFilter_Validate_invalid_network(t *testing.T) {
+	data := `
+name: office-only
+ipv4:
+  - 10.0.0.0/8
+  - 192.168.1.300
+`
+	var obj ExpectedIPFilter
+	err := yaml.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = obj.Validate()
+	expected := "office-only: invalid network \"192.168.1.300\""
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// getPoolID returns the ID of the pool. It supports both an integer and a
+// string `@pool:Name`. Only pools of the same type as the record are matched
+func getPoolID(pool interface{}, recordType string) (int, error) {
+	switch v := pool.(type) {
+	case string:
+		if !strings.HasPrefix(v, "@pool:") {
+			return 0, fmt.Errorf("invalid pool value. Expected @pool:<name> or int")
+		}
+		name := strings.TrimPrefix(v, "@pool:")
+		name = strings.TrimSpace(name)
+		pools, err := GetPools()
+		if err != nil {
+			return 0, err
+		}
+		for _, p := range pools {
+			if p.Name == name && p.Type == recordType {
+				return p.ID, nil
+			}
+		}
+		if resolveFromConfig {
+			for _, p := range configuredPools {
+				if p.Name == name && p.Type == recordType {
+					pendingReferences = append(pendingReferences, v)
+					return 0, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("unable to find %s pool %s", recordType, name)
+	case int, float64:
+		return toInt(pool), nil
+	default:
+		return 0, fmt.Errorf("invalid pool value. Expected @pool:<name> or int")
+	}
+}
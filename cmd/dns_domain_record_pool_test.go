@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestGetPoolID_ValidStringInput(t *testing.T) {
+	// Mock the GetPools function to control its behavior for testing
+	oldGetPools := GetPools
+	defer func() { GetPools = oldGetPools }()
+	GetPools = func() ([]*Pool, error) {
+		return []*Pool{
+			{ID: 1, Name: "test", Type: "AAAA"},
+			{ID: 2, Name: "test", Type: "A"},
+		}, nil
+	}
+
+	got, err := getPoolID("@pool: test", "A")
+	if err != nil {
+		t.Errorf("getPoolID() error = %v, want error %v", err, false)
+	}
+	if got != 2 {
+		t.Errorf("getPoolID() = %v, want %v", got, 2)
+	}
+}
+
+func TestGetPoolID_cached(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(v4TestResponse(`[{"id":2,"name":"test","type":"A"}]`)))
+	}))
+	defer ts.Close()
+	originalDNSRESTAPIBaseURL := dnsRESTAPIBaseURL
+	resetCache()
+	defer func() {
+		dnsRESTAPIBaseURL = originalDNSRESTAPIBaseURL
+		resetCache()
+	}()
+	dnsRESTAPIBaseURL = ts.URL
+
+	for _, pool := range []string{"@pool:test", "@pool:test", "@pool:missing"} {
+		getPoolID(pool, "A")
+	}
+	if requests != 1 {
+		t.Errorf("want Pools to be retrieved once, got %d requests", requests)
+	}
+	resetCache()
+	got, err := getPoolID("@pool:test", "A")
+	if err != nil || got != 2 {
+		t.Errorf("getPoolID() = %v, %v, want %v", got, err, 2)
+	}
+	if requests != 2 {
+		t.Errorf("want Pools to be retrieved again after resetCache, got %d requests", requests)
+	}
+}
+
+func TestGetPoolID_NotFound(t *testing.T) {
+	oldGetPools := GetPools
+	defer func() { GetPools = oldGetPools }()
+	GetPools = func() ([]*Pool, error) {
+		return []*Pool{
+			{ID: 1, Name: "test", Type: "AAAA"},
+		}, nil
+	}
+
+	_, err := getPoolID("@pool:test", "A")
+	expected := "unable to find A pool test"
+	if err == nil || err.Error() != expected {
+		t.Errorf("getPoolID() error = %v, want error %q", err, expected)
+	}
+}
+
+func TestExpectedDNSRecord_Pools_Reference(t *testing.T) {
+	oldGetPools := GetPools
+	defer func() { GetPools = oldGetPools }()
+	GetPools = func() ([]*Pool, error) {
+		return []*Pool{
+			{ID: 7, Name: "web", Type: "A"},
+		}, nil
+	}
+
+	data := `
+name: abc
+type: A
+mode: pools
+value:
+  - "@pool:web"
+  - 8
+`
+	var obj ExpectedDNSRecord
+	err := yaml.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := []int{7, 8}
+	if !reflect.DeepEqual(obj.Value, expected) {
+		t.Errorf("expected %v, got %v", expected, obj.Value)
+	}
+}
+
+func TestExpectedPool_UnmarshalYAML(t *testing.T) {
+	originalCache := cachedSonarHTTPChecks
+	defer func() { cachedSonarHTTPChecks = originalCache }()
+	cachedSonarHTTPChecks = []*SonarHTTPCheck{{ID: 11, Name: "web-1", Host: "1.1.1.1"}}
+
+	data := `
+name: web
+type: A
+return: 1
+minimumFailover: 1
+enabled: true
+values:
+  - weight: 1
+    enabled: true
+    policy: followsonar
+    sonarCheckId: "@sonar,http:web-1"
+`
+	var obj ExpectedPool
+	err := yaml.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = obj.Validate()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := []*PoolValue{
+		{Value: "1.1.1.1", Weight: 1, Enabled: true, Policy: "followsonar", SonarCheckID: 11},
+	}
+	if !reflect.DeepEqual(obj.Values, expected) {
+		t.Errorf("expected %v, got %v", expected, obj.Values)
+	}
+	if obj.GetResourceID() != `A "web"` {
+		t.Errorf("expected %q, got %q", `A "web"`, obj.GetResourceID())
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// PTR records in reverse zones can be declared with an IP address instead of
+// the name, e.g.:
+//
+//	- ip: 192.168.1.10
+//	  type: PTR
+//	  ...
+//
+// The name is computed within the configured reverse domain
+// (1.168.192.in-addr.arpa -> "10")
+
+// resolvePTRName sets the name of the record from its IP address
+func (ex *ExpectedDNSRecord) resolvePTRName(domainName string) error {
+	if ex.ip == "" {
+		return nil
+	}
+	if ex.Type != "PTR" {
+		return fmt.Errorf("%s: ip is supported only for PTR records", ex.GetResourceID())
+	}
+	if _, ok := ex.definedFieldsMap["name"]; ok {
+		return fmt.Errorf("%s: ip and name can't be defined at the same time", ex.GetResourceID())
+	}
+	name, err := getReverseName(ex.ip, domainName)
+	if err != nil {
+		return err
+	}
+	ex.Name = name
+	ex.definedFieldsMap["name"] = "Name"
+	return nil
+}
+
+// getReverseName returns the name of the PTR record for the IP address relative
+// to the reverse domain (in-addr.arpa or ip6.arpa)
+func getReverseName(ip string, domainName string) (string, error) {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return "", fmt.Errorf("invalid IP address %q", ip)
+	}
+
+	var labels []string
+	if ipv4 := parsedIP.To4(); ipv4 != nil {
+		for i := len(ipv4) - 1; i >= 0; i-- {
+			labels = append(labels, fmt.Sprint(ipv4[i]))
+		}
+		labels = append(labels, "in-addr", "arpa")
+	} else {
+		for i := len(parsedIP) - 1; i >= 0; i-- {
+			labels = append(labels, fmt.Sprintf("%x", parsedIP[i]&0x0f), fmt.Sprintf("%x", parsedIP[i]>>4))
+		}
+		labels = append(labels, "ip6", "arpa")
+	}
+	fullName := strings.Join(labels, ".")
+
+	domainName = strings.ToLower(strings.TrimSuffix(domainName, "."))
+	if fullName == domainName {
+		return "", nil
+	}
+	if !strings.HasSuffix(fullName, "."+domainName) {
+		return "", fmt.Errorf("IP address %s is not within reverse domain %s", ip, domainName)
+	}
+	return strings.TrimSuffix(fullName, "."+domainName), nil
+}
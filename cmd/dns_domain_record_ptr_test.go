@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestGetReverseName(t *testing.T) {
+	tests := []struct {
+		ip     string
+		domain string
+		want   string
+	}{
+		{"192.168.1.10", "1.168.192.in-addr.arpa", "10"},
+		{"192.168.1.10", "168.192.in-addr.arpa.", "10.1"},
+		{"2001:db8::1", "8.b.d.0.1.0.0.2.ip6.arpa", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0"},
+	}
+	for _, tt := range tests {
+		got, err := getReverseName(tt.ip, tt.domain)
+		if err != nil {
+			t.Errorf("getReverseName(%q, %q) error = %v", tt.ip, tt.domain, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("getReverseName(%q, %q) = %q, want %q", tt.ip, tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestGetReverseName_OutsideOfDomain(t *testing.T) {
+	_, err := getReverseName("10.0.0.1", "1.168.192.in-addr.arpa")
+	expected := "IP address 10.0.0.1 is not within reverse domain 1.168.192.in-addr.arpa"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
+
+func TestExpectedDNSRecord_ResolvePTRName(t *testing.T) {
+	data := `
+ip: 192.168.1.10
+type: PTR
+ttl: 3600
+mode: standard
+region: default
+enabled: true
+value:
+  - value: host.example.com.
+    enabled: true
+`
+	var obj ExpectedDNSRecord
+	err := yaml.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = obj.resolvePTRName("1.168.192.in-addr.arpa")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if obj.Name != "10" {
+		t.Errorf("expected %q, got %q", "10", obj.Name)
+		return
+	}
+	if obj.definedFieldsMap["name"] != "Name" {
+		t.Errorf("expected name to be defined, got %v", obj.definedFieldsMap)
+	}
+}
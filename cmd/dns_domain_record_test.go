@@ -655,3 +655,310 @@ notes: ""
 	Compare(objJ, expectedValue, t)
 	Compare(objY, expectedValue, t)
 }
+
+func TestSRVRecord(t *testing.T) {
+	dataJ := `{"id":12494734,"name":"_sip._udp","type":"SRV","ttl":3600,"mode":"standard","region":"default","ipfilter":null,"ipfilterDrop":false,"geoFailover":false,"geoproximity":null,"enabled":true,"value":[{"host":"sip.example.com.","priority":10,"weight":20,"port":5060,"enabled":true}]}`
+	dataY := `
+name: _sip._udp
+type: SRV
+ttl: 3600
+mode: standard
+region: default
+enabled: true
+value:
+  - host: sip.example.com.
+    priority: 10
+    weight: 20
+    port: 5060
+    enabled: true
+`
+	var objJ DNSRecord
+	err := json.Unmarshal([]byte(dataJ), &objJ)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var objY ExpectedDNSRecord
+	err = yaml.Unmarshal([]byte(dataY), &objY)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expectedValue := []*DNSSRVStandardItemValue{
+		{
+			Host:     "sip.example.com.",
+			Priority: 10,
+			Weight:   20,
+			Port:     5060,
+			Enabled:  true,
+		},
+	}
+	if !reflect.DeepEqual(objJ.Value, expectedValue) {
+		t.Errorf("expected %v, got %v", expectedValue, objJ.Value)
+		return
+	}
+	if !reflect.DeepEqual(objY.Value, expectedValue) {
+		t.Errorf("expected %v, got %v", expectedValue, objY.Value)
+		return
+	}
+
+	// Discovered record must produce configuration which doesn't require changes
+	dataB, err := yaml.Marshal(&objJ)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var objRoundTrip ExpectedDNSRecord
+	err = yaml.Unmarshal(dataB, &objRoundTrip)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	action, diffs, err := Compare(&objRoundTrip, &objJ)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if action != ActionOK {
+		t.Errorf("expected %q, got %q: %v", ActionOK, action, diffs)
+	}
+}
+
+func TestCAARecord(t *testing.T) {
+	dataJ := `{"id":12494735,"name":"","type":"CAA","ttl":3600,"mode":"standard","region":"default","ipfilter":null,"ipfilterDrop":false,"geoFailover":false,"geoproximity":null,"enabled":true,"value":[{"flag":0,"tag":"issue","data":"letsencrypt.org","enabled":true},{"flag":128,"tag":"iodef","data":"mailto:security@example.com","enabled":true}]}`
+	var objJ DNSRecord
+	err := json.Unmarshal([]byte(dataJ), &objJ)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expectedValue := []*DNSCAAStandardItemValue{
+		{Flag: 0, Tag: "issue", Data: "letsencrypt.org", Enabled: true},
+		{Flag: 128, Tag: "iodef", Data: "mailto:security@example.com", Enabled: true},
+	}
+	if !reflect.DeepEqual(objJ.Value, expectedValue) {
+		t.Errorf("expected %v, got %v", expectedValue, objJ.Value)
+		return
+	}
+
+	dataB, err := yaml.Marshal(&objJ)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var objY ExpectedDNSRecord
+	err = yaml.Unmarshal(dataB, &objY)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = objY.Validate()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(objY.Value, expectedValue) {
+		t.Errorf("expected %v, got %v", expectedValue, objY.Value)
+		return
+	}
+}
+
+func TestCAARecord_Validate(t *testing.T) {
+	tests := map[string]string{
+		"issue":  "",
+		"issuer": `CAA "" (default, 0): invalid CAA tag "issuer", expected one of ["issue" "issuewild" "iodef"]`,
+		"iodef":  `CAA "" (default, 0): invalid CAA iodef URL "letsencrypt.org", expected mailto:, http: or https: scheme`,
+	}
+	for tag, expected := range tests {
+		data := `
+type: CAA
+mode: standard
+region: default
+value:
+  - flag: 0
+    tag: ` + tag + `
+    data: letsencrypt.org
+    enabled: true
+`
+		var obj ExpectedDNSRecord
+		err := yaml.Unmarshal([]byte(data), &obj)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		err = obj.Validate()
+		if expected == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %s", tag, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != expected {
+			t.Errorf("%s: expected error %q, got %v", tag, expected, err)
+		}
+	}
+}
+
+func TestNSRecord(t *testing.T) {
+	data := `
+name: sub
+type: NS
+ttl: 86400
+mode: standard
+region: default
+enabled: true
+value:
+  - value: ns1.other-provider.com.
+    enabled: true
+`
+	var obj ExpectedDNSRecord
+	err := yaml.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = obj.Validate()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expectedValue := []*DNSStandardItemValue{
+		{Value: "ns1.other-provider.com.", Enabled: true},
+	}
+	if !reflect.DeepEqual(obj.Value, expectedValue) {
+		t.Errorf("expected %v, got %v", expectedValue, obj.Value)
+		return
+	}
+}
+
+func Test_planDNSRecords_ignore_apex_NS(t *testing.T) {
+	api := newFakeConstellixAPI(t)
+	api.add("/domains", `[{"id":1,"name":"example.com"}]`)
+	api.add("/domains/1/records", `[
+{"id":1,"name":"","type":"NS","mode":"standard","value":[{"value":"ns11.constellix.com.","enabled":true}]},
+{"id":2,"name":"sub","type":"NS","mode":"standard","value":[{"value":"ns1.other-provider.com.","enabled":true}]}]`)
+	configFile := writeTestConfig(t, map[string]string{
+		"config.yaml": `constellix:
+  dns:
+    example.com: [records.yaml]
+`,
+		"records.yaml": `- name: sub
+  type: NS
+  mode: standard
+  value:
+    - value: ns1.other-provider.com.
+      enabled: true
+`,
+	})
+	config, err := getConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Apex NS records are retrieved, but they are not proposed for deletion
+	records, err := GetDNSRecords(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Errorf("expected 2 records, got %d", len(records))
+	}
+	plans, err := planDNSRecords(config, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 1 || len(plans[0].Entries) != 1 {
+		t.Fatalf("expected 1 plan with 1 entry, got %+v", plans)
+	}
+	if entry := plans[0].Entries[0]; entry.Action != ActionOK || entry.ConstellixID != 2 {
+		t.Errorf("expected record 2 to be in sync, got %s %d", entry.Action, entry.ConstellixID)
+	}
+	if len(plans[0].dnsRecords) != 2 {
+		t.Errorf("expected apex NS record in the snapshot records, got %d records", len(plans[0].dnsRecords))
+	}
+}
+
+func TestLegacyRecordTypes(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		expected interface{}
+	}{
+		"NAPTR": {
+			`[{"order":10,"preference":100,"flags":"S","service":"SIP+D2U","regularExpression":"","replacement":"_sip._udp.example.com.","enabled":true}]`,
+			[]*DNSNAPTRStandardItemValue{
+				{Order: 10, Preference: 100, Flags: "S", Service: "SIP+D2U", Replacement: "_sip._udp.example.com.", Enabled: true},
+			},
+		},
+		"CERT": {
+			`[{"certificateType":1,"keyTag":12345,"algorithm":8,"certificate":"MIIB","enabled":true}]`,
+			[]*DNSCERTStandardItemValue{
+				{CertificateType: 1, KeyTag: 12345, Algorithm: 8, Certificate: "MIIB", Enabled: true},
+			},
+		},
+		"HINFO": {
+			`[{"cpu":"x86_64","os":"Linux","enabled":true}]`,
+			[]*DNSHINFOStandardItemValue{
+				{CPU: "x86_64", OS: "Linux", Enabled: true},
+			},
+		},
+		"RP": {
+			`[{"mailbox":"admin.example.com.","txt":"info.example.com.","enabled":true}]`,
+			[]*DNSRPStandardItemValue{
+				{Mailbox: "admin.example.com.", TXT: "info.example.com.", Enabled: true},
+			},
+		},
+		"SPF": {
+			`[{"value":"v=spf1 -all","enabled":true}]`,
+			[]*DNSStandardItemValue{
+				{Value: "v=spf1 -all", Enabled: true},
+			},
+		},
+	}
+	for recordType, tt := range tests {
+		data := `{"id":1,"name":"abc","type":"` + recordType + `","ttl":3600,"mode":"standard","region":"default","enabled":true,"value":` + tt.value + `}`
+		var objJ DNSRecord
+		err := json.Unmarshal([]byte(data), &objJ)
+		if err != nil {
+			t.Errorf("%s: %s", recordType, err)
+			continue
+		}
+		if !reflect.DeepEqual(objJ.Value, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", recordType, tt.expected, objJ.Value)
+			continue
+		}
+
+		dataB, err := yaml.Marshal(&objJ)
+		if err != nil {
+			t.Errorf("%s: %s", recordType, err)
+			continue
+		}
+		var objY ExpectedDNSRecord
+		err = yaml.Unmarshal(dataB, &objY)
+		if err != nil {
+			t.Errorf("%s: %s", recordType, err)
+			continue
+		}
+		action, _, err := Compare(&objY, &objJ)
+		if err != nil {
+			t.Errorf("%s: %s", recordType, err)
+			continue
+		}
+		if action != ActionOK {
+			t.Errorf("%s: expected %q, got %q", recordType, ActionOK, action)
+			continue
+		}
+
+		// Changes in ttl and value must be detected
+		objY.TTL = 60
+		objY.Value = reflect.ValueOf(objY.Value).Slice(0, 0).Interface()
+		action, diffs, err := Compare(&objY, &objJ)
+		if err != nil {
+			t.Errorf("%s: %s", recordType, err)
+			continue
+		}
+		if action != ActionUpate || len(diffs) != 2 {
+			t.Errorf("%s: expected %q with 2 diffs, got %q with %d", recordType, ActionUpate, action, len(diffs))
+		}
+	}
+}
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
+
+	"golang.org/x/exp/slices"
 )
 
 type DNSStandardItemValue struct {
@@ -38,6 +41,53 @@ type DNSHTTPStandardItemValue struct {
 	URL          string `json:"url" yaml:"url"`
 }
 
+type DNSSRVStandardItemValue struct {
+	Host     string `json:"host" yaml:"host"` // Target host of the service
+	Priority int    `json:"priority" yaml:"priority"`
+	Weight   int    `json:"weight" yaml:"weight"`
+	Port     int    `json:"port" yaml:"port"`
+	Enabled  bool   `json:"enabled" yaml:"enabled"`
+}
+
+type DNSCAAStandardItemValue struct {
+	Flag    int    `json:"flag" yaml:"flag"`
+	Tag     string `json:"tag" yaml:"tag"` // One of issue, issuewild, iodef
+	Data    string `json:"data" yaml:"data"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+}
+
+type DNSNAPTRStandardItemValue struct {
+	Order             int    `json:"order" yaml:"order"`
+	Preference        int    `json:"preference" yaml:"preference"`
+	Flags             string `json:"flags" yaml:"flags"`
+	Service           string `json:"service" yaml:"service"`
+	RegularExpression string `json:"regularExpression" yaml:"regularExpression"`
+	Replacement       string `json:"replacement" yaml:"replacement"`
+	Enabled           bool   `json:"enabled" yaml:"enabled"`
+}
+
+type DNSCERTStandardItemValue struct {
+	CertificateType int    `json:"certificateType" yaml:"certificateType"`
+	KeyTag          int    `json:"keyTag" yaml:"keyTag"`
+	Algorithm       int    `json:"algorithm" yaml:"algorithm"`
+	Certificate     string `json:"certificate" yaml:"certificate"`
+	Enabled         bool   `json:"enabled" yaml:"enabled"`
+}
+
+type DNSHINFOStandardItemValue struct {
+	CPU     string `json:"cpu" yaml:"cpu"`
+	OS      string `json:"os" yaml:"os"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+}
+
+type DNSRPStandardItemValue struct {
+	Mailbox string `json:"mailbox" yaml:"mailbox"`
+	TXT     string `json:"txt" yaml:"txt"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+}
+
+var supportedCAATags = []string{"issue", "issuewild", "iodef"}
+
 type aliasDNSRecord DNSRecord
 
 // populateDNSRecordValue populates the Value field of a DNSRecord based on the
@@ -137,7 +187,11 @@ func populateDNSRecordValue(record interface{}) error {
 			}
 			valueObj := make([]int, 0)
 			for _, el := range m {
-				valueObj = append(valueObj, toInt(el))
+				poolID, err := getPoolID(el, s.Type)
+				if err != nil {
+					return err
+				}
+				valueObj = append(valueObj, poolID)
 			}
 			s.Value = valueObj
 		default:
@@ -165,19 +219,19 @@ func populateDNSRecordValue(record interface{}) error {
 			valueObj = append(valueObj, &valueEl)
 		}
 		s.Value = valueObj
-	case "TXT":
+	case "TXT", "NS", "PTR", "SPF":
 		if s.Mode != "standard" {
-			return fmt.Errorf("unsupported mode %q for TXT record", s.Mode)
+			return fmt.Errorf("unsupported mode %q for %s record", s.Mode, s.Type)
 		}
 		m, ok := s.Value.([]interface{})
 		if !ok {
-			return fmt.Errorf("unable to parse value for TXT record in standard mode, expected an array")
+			return fmt.Errorf("unable to parse value for %s record in standard mode, expected an array", s.Type)
 		}
 		valueObj := make([]*DNSStandardItemValue, 0)
 		for _, el := range m {
 			elMap, ok := el.(map[string]interface{})
 			if !ok {
-				return fmt.Errorf("unable to parse value for TXT record in standard mode, expected an map")
+				return fmt.Errorf("unable to parse value for %s record in standard mode, expected an map", s.Type)
 			}
 			valueEl := DNSStandardItemValue{
 				Value:   elMap["value"].(string),
@@ -202,12 +256,186 @@ func populateDNSRecordValue(record interface{}) error {
 		valueObj.Keywords, _ = m["keywords"].(string)
 		valueObj.Description, _ = m["description"].(string)
 		s.Value = valueObj
+	case "SRV":
+		if s.Mode != "standard" {
+			return fmt.Errorf("unsupported mode %q for SRV record", s.Mode)
+		}
+		m, ok := s.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("unable to parse value for SRV record in standard mode, expected an array")
+		}
+		valueObj := make([]*DNSSRVStandardItemValue, 0)
+		for _, el := range m {
+			elMap, ok := el.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("unable to parse value for SRV record in standard mode, expected an map")
+			}
+			valueEl := DNSSRVStandardItemValue{
+				Priority: toInt(elMap["priority"]),
+				Weight:   toInt(elMap["weight"]),
+				Port:     toInt(elMap["port"]),
+			}
+			valueEl.Host, _ = elMap["host"].(string)
+			valueEl.Enabled, _ = elMap["enabled"].(bool)
+			valueObj = append(valueObj, &valueEl)
+		}
+		s.Value = valueObj
+	case "CAA":
+		if s.Mode != "standard" {
+			return fmt.Errorf("unsupported mode %q for CAA record", s.Mode)
+		}
+		m, ok := s.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("unable to parse value for CAA record in standard mode, expected an array")
+		}
+		valueObj := make([]*DNSCAAStandardItemValue, 0)
+		for _, el := range m {
+			elMap, ok := el.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("unable to parse value for CAA record in standard mode, expected an map")
+			}
+			valueEl := DNSCAAStandardItemValue{
+				Flag: toInt(elMap["flag"]),
+			}
+			valueEl.Tag, _ = elMap["tag"].(string)
+			valueEl.Data, _ = elMap["data"].(string)
+			valueEl.Enabled, _ = elMap["enabled"].(bool)
+			valueObj = append(valueObj, &valueEl)
+		}
+		s.Value = valueObj
+	case "NAPTR":
+		if s.Mode != "standard" {
+			return fmt.Errorf("unsupported mode %q for NAPTR record", s.Mode)
+		}
+		m, ok := s.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("unable to parse value for NAPTR record in standard mode, expected an array")
+		}
+		valueObj := make([]*DNSNAPTRStandardItemValue, 0)
+		for _, el := range m {
+			elMap, ok := el.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("unable to parse value for NAPTR record in standard mode, expected an map")
+			}
+			valueEl := DNSNAPTRStandardItemValue{
+				Order:      toInt(elMap["order"]),
+				Preference: toInt(elMap["preference"]),
+			}
+			valueEl.Flags, _ = elMap["flags"].(string)
+			valueEl.Service, _ = elMap["service"].(string)
+			valueEl.RegularExpression, _ = elMap["regularExpression"].(string)
+			valueEl.Replacement, _ = elMap["replacement"].(string)
+			valueEl.Enabled, _ = elMap["enabled"].(bool)
+			valueObj = append(valueObj, &valueEl)
+		}
+		s.Value = valueObj
+	case "CERT":
+		if s.Mode != "standard" {
+			return fmt.Errorf("unsupported mode %q for CERT record", s.Mode)
+		}
+		m, ok := s.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("unable to parse value for CERT record in standard mode, expected an array")
+		}
+		valueObj := make([]*DNSCERTStandardItemValue, 0)
+		for _, el := range m {
+			elMap, ok := el.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("unable to parse value for CERT record in standard mode, expected an map")
+			}
+			valueEl := DNSCERTStandardItemValue{
+				CertificateType: toInt(elMap["certificateType"]),
+				KeyTag:          toInt(elMap["keyTag"]),
+				Algorithm:       toInt(elMap["algorithm"]),
+			}
+			valueEl.Certificate, _ = elMap["certificate"].(string)
+			valueEl.Enabled, _ = elMap["enabled"].(bool)
+			valueObj = append(valueObj, &valueEl)
+		}
+		s.Value = valueObj
+	case "HINFO":
+		if s.Mode != "standard" {
+			return fmt.Errorf("unsupported mode %q for HINFO record", s.Mode)
+		}
+		m, ok := s.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("unable to parse value for HINFO record in standard mode, expected an array")
+		}
+		valueObj := make([]*DNSHINFOStandardItemValue, 0)
+		for _, el := range m {
+			elMap, ok := el.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("unable to parse value for HINFO record in standard mode, expected an map")
+			}
+			valueEl := DNSHINFOStandardItemValue{}
+			valueEl.CPU, _ = elMap["cpu"].(string)
+			valueEl.OS, _ = elMap["os"].(string)
+			valueEl.Enabled, _ = elMap["enabled"].(bool)
+			valueObj = append(valueObj, &valueEl)
+		}
+		s.Value = valueObj
+	case "RP":
+		if s.Mode != "standard" {
+			return fmt.Errorf("unsupported mode %q for RP record", s.Mode)
+		}
+		m, ok := s.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("unable to parse value for RP record in standard mode, expected an array")
+		}
+		valueObj := make([]*DNSRPStandardItemValue, 0)
+		for _, el := range m {
+			elMap, ok := el.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("unable to parse value for RP record in standard mode, expected an map")
+			}
+			valueEl := DNSRPStandardItemValue{}
+			valueEl.Mailbox, _ = elMap["mailbox"].(string)
+			valueEl.TXT, _ = elMap["txt"].(string)
+			valueEl.Enabled, _ = elMap["enabled"].(bool)
+			valueObj = append(valueObj, &valueEl)
+		}
+		s.Value = valueObj
 	default:
 		return fmt.Errorf("unsupported record type %q", s.Type)
 	}
 	return nil
 }
 
+// validateDNSRecordValue validates the populated Value field of a DNSRecord.
+// Only record types with restricted values are validated
+func validateDNSRecordValue(s *DNSRecord) error {
+	switch value := s.Value.(type) {
+	case []*DNSCAAStandardItemValue:
+		for _, item := range value {
+			if item.Flag < 0 || item.Flag > 255 {
+				return fmt.Errorf("invalid CAA flag %d, expected a value between 0 and 255", item.Flag)
+			}
+			if !slices.Contains(supportedCAATags, item.Tag) {
+				return fmt.Errorf("invalid CAA tag %q, expected one of %q", item.Tag, supportedCAATags)
+			}
+			if item.Tag == "iodef" {
+				u, err := url.Parse(item.Data)
+				if err != nil {
+					return fmt.Errorf("invalid CAA iodef URL %q: %s", item.Data, err)
+				}
+				switch u.Scheme {
+				case "mailto":
+					if u.Opaque == "" {
+						return fmt.Errorf("invalid CAA iodef URL %q, expected an email address", item.Data)
+					}
+				case "http", "https":
+					if u.Host == "" {
+						return fmt.Errorf("invalid CAA iodef URL %q, expected a host", item.Data)
+					}
+				default:
+					return fmt.Errorf("invalid CAA iodef URL %q, expected mailto:, http: or https: scheme", item.Data)
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func toInt(i interface{}) int {
 	switch v := i.(type) {
 	case int:
@@ -226,25 +454,103 @@ func getSonarCheckID(i interface{}) (int, string, error) {
 		if err != nil {
 			return 0, "", err
 		}
-		switch checkType {
-		case "http":
-			checks, err := GetSonarHTTPChecks()
-			if err != nil {
-				return 0, "", err
+		checks, configured, err := getSonarCheckReferences(checkType)
+		if err != nil {
+			return 0, "", err
+		}
+		for _, check := range checks {
+			if check.Name == checkName {
+				return check.ID, check.Host, nil
 			}
-			for _, check := range checks {
-				if check.GetResourceID() == checkName {
-					return check.ID, check.Host, nil
+		}
+		if resolveFromConfig {
+			for _, check := range configured {
+				if check.Name == checkName {
+					pendingReferences = append(pendingReferences, v)
+					return 0, check.Host, nil
 				}
 			}
-			return 0, "", fmt.Errorf("unable to find sonar check %s:%s", checkType, checkName)
-		default:
-			return 0, "", fmt.Errorf("unsupported check type: %s", checkType)
 		}
+		return 0, "", fmt.Errorf("unable to find sonar check %s:%s", checkType, checkName)
 	}
 	return toInt(i), "", nil
 }
 
+// sonarCheckReference is a Sonar check which can be referenced as
+// @sonar,<check_type>:<check_name>. Host of the check replaces values of
+// failover records and pools, DNS checks have no host
+type sonarCheckReference struct {
+	ID   int
+	Name string
+	Host string
+}
+
+// getSonarCheckReferences returns Sonar checks of the type which exist in
+// Constellix and which are defined in the configuration
+func getSonarCheckReferences(checkType string) ([]*sonarCheckReference, []*sonarCheckReference, error) {
+	var checks, configured []*sonarCheckReference
+	switch checkType {
+	case "http":
+		active, err := GetSonarHTTPChecks()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, check := range active {
+			checks = append(checks, &sonarCheckReference{check.ID, check.GetResourceID(), check.Host})
+		}
+		for _, check := range configuredSonarHTTPChecks {
+			configured = append(configured, &sonarCheckReference{0, check.GetResourceID(), check.Host})
+		}
+	case "tcp":
+		active, err := GetSonarTCPChecks()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, check := range active {
+			checks = append(checks, &sonarCheckReference{check.ID, check.GetResourceID(), check.Host})
+		}
+		for _, check := range configuredSonarTCPChecks {
+			configured = append(configured, &sonarCheckReference{0, check.GetResourceID(), check.Host})
+		}
+	case "icmp":
+		active, err := GetSonarICMPChecks()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, check := range active {
+			checks = append(checks, &sonarCheckReference{check.ID, check.GetResourceID(), check.Host})
+		}
+		for _, check := range configuredSonarICMPChecks {
+			configured = append(configured, &sonarCheckReference{0, check.GetResourceID(), check.Host})
+		}
+	case "dns":
+		active, err := GetSonarDNSChecks()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, check := range active {
+			checks = append(checks, &sonarCheckReference{check.ID, check.GetResourceID(), ""})
+		}
+		for _, check := range configuredSonarDNSChecks {
+			configured = append(configured, &sonarCheckReference{0, check.GetResourceID(), ""})
+		}
+	case "ssl":
+		active, err := GetSonarSSLChecks()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, check := range active {
+			checks = append(checks, &sonarCheckReference{check.ID, check.GetResourceID(), check.Host})
+		}
+		for _, check := range configuredSonarSSLChecks {
+			configured = append(configured, &sonarCheckReference{0, check.GetResourceID(), check.Host})
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported check type: %s", checkType)
+	}
+	return checks, configured, nil
+}
+
 // parseSonarCheckID parses a sonar check ID from a string. It assumes that the string
 // will start with a @, followed by code word 'sonar' with specified check type and the
 // name of the check itself
@@ -0,0 +1,508 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Maximum length of a character string in TXT records (RFC 1035)
+const maxZoneStringLength = 255
+
+// activeValueResolver finds values which are currently served by records in
+// failover, roundrobin-failover and pools modes. Values with Sonar checks
+// which are down are not served
+type activeValueResolver struct {
+	checkTypes map[int]string
+	statuses   map[int]ResourceRuntimeStatus
+	pools      map[int]*Pool
+	// lookupIP resolves targets of ANAME records
+	lookupIP func(host string) ([]net.IP, error)
+}
+
+func newActiveValueResolver() *activeValueResolver {
+	return &activeValueResolver{statuses: map[int]ResourceRuntimeStatus{}, lookupIP: net.LookupIP}
+}
+
+// isDown checks if the Sonar check reports the value as down. Values without
+// checks or with unknown status are considered up
+func (r *activeValueResolver) isDown(checkID int) bool {
+	if checkID == 0 {
+		return false
+	}
+	if status, ok := r.statuses[checkID]; ok {
+		return status == StatusDown
+	}
+	if r.checkTypes == nil {
+		err := r.loadCheckTypes()
+		if err != nil {
+			logger.Printf("  unable to retrieve Sonar checks, all values are considered up: %s\n", err)
+		}
+	}
+	status := ResourceRuntimeStatus("unknown")
+	if checkType, ok := r.checkTypes[checkID]; ok {
+		var err error
+		status, err = getSonarCheckStatus(checkType, checkID)
+		if err != nil {
+			logger.Printf("  unable to retrieve status of Sonar check %d: %s\n", checkID, err)
+		}
+	}
+	r.statuses[checkID] = status
+	return status == StatusDown
+}
+
+func (r *activeValueResolver) loadCheckTypes() error {
+	r.checkTypes = map[int]string{}
+	httpChecks, err := GetSonarHTTPChecks()
+	if err != nil {
+		return err
+	}
+	for _, check := range httpChecks {
+		r.checkTypes[check.ID] = "http"
+	}
+	tcpChecks, err := GetSonarTCPChecks()
+	if err != nil {
+		return err
+	}
+	for _, check := range tcpChecks {
+		r.checkTypes[check.ID] = "tcp"
+	}
+	icmpChecks, err := GetSonarICMPChecks()
+	if err != nil {
+		return err
+	}
+	for _, check := range icmpChecks {
+		r.checkTypes[check.ID] = "icmp"
+	}
+	dnsChecks, err := GetSonarDNSChecks()
+	if err != nil {
+		return err
+	}
+	for _, check := range dnsChecks {
+		r.checkTypes[check.ID] = "dns"
+	}
+	sslChecks, err := GetSonarSSLChecks()
+	if err != nil {
+		return err
+	}
+	for _, check := range sslChecks {
+		r.checkTypes[check.ID] = "ssl"
+	}
+	return nil
+}
+
+func (r *activeValueResolver) getPool(id int) (*Pool, error) {
+	if r.pools == nil {
+		pools, err := GetPools()
+		if err != nil {
+			return nil, err
+		}
+		r.pools = map[int]*Pool{}
+		for _, pool := range pools {
+			r.pools[pool.ID] = pool
+		}
+	}
+	pool, ok := r.pools[id]
+	if !ok {
+		return nil, fmt.Errorf("pool %d not found", id)
+	}
+	return pool, nil
+}
+
+// activeFailoverValues returns values of failover and roundrobin-failover
+// records which are served now. Only the first healthy value is served in
+// failover mode. If all values are down, all enabled values are returned
+func (r *activeValueResolver) activeFailoverValues(values []*DNSFailoverItemValue, firstOnly bool) []string {
+	sorted := slices.Clone(values)
+	slices.SortStableFunc(sorted, func(a, b *DNSFailoverItemValue) int {
+		return a.Order - b.Order
+	})
+	var enabled, active []string
+	for _, item := range sorted {
+		if !item.Enabled {
+			continue
+		}
+		enabled = append(enabled, item.Value)
+		if !r.isDown(item.SonarCheckID) {
+			active = append(active, item.Value)
+		}
+	}
+	if len(active) == 0 {
+		active = enabled
+	}
+	if firstOnly && len(active) > 1 {
+		active = active[:1]
+	}
+	return active
+}
+
+// activePoolValues returns healthy values of pools
+func (r *activeValueResolver) activePoolValues(poolIDs []int) ([]string, []string, error) {
+	var names, active []string
+	for _, id := range poolIDs {
+		pool, err := r.getPool(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		names = append(names, pool.Name)
+		var enabled []string
+		healthy := 0
+		for _, item := range pool.Values {
+			if !item.Enabled {
+				continue
+			}
+			enabled = append(enabled, item.Value)
+			if !r.isDown(item.SonarCheckID) {
+				active = append(active, item.Value)
+				healthy++
+			}
+		}
+		if healthy == 0 {
+			active = append(active, enabled...)
+		}
+	}
+	return names, active, nil
+}
+
+// zoneRecord is a DNS record rendered as resource records of a zone file.
+// Records which can't be served by a plain DNS server are commented out
+type zoneRecord struct {
+	recordType string
+	comments   []string
+	rdata      []string
+	commentOut bool
+}
+
+// renderBINDZone writes DNS records of the domain as a zone file. Records in
+// Constellix-specific modes are rendered using currently served values with a
+// comment describing the original mode. A plain DNS server serves the same
+// answer to all clients, so only one record of each name and type is rendered:
+// the one served by default or, if there is none, the first one served in a
+// GTD region or to clients near a GeoProximity. Other records are commented out
+func renderBINDZone(w io.Writer, domain *DNSDomain, records []*DNSRecord, resolver *activeValueResolver) error {
+	origin := absoluteName(domain.Name)
+	// Apex NS records are rendered from name servers of the domain
+	sorted := withoutApexNS(records)
+	slices.SortStableFunc(sorted, func(a, b *DNSRecord) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Type, b.Type); c != 0 {
+			return c
+		}
+		// Records served by default go first, disabled ones go last
+		return zoneRecordRank(a) - zoneRecordRank(b)
+	})
+	// ANAME can be rendered as CNAME only if there are no other records
+	types := map[string]map[string]bool{}
+	for _, record := range sorted {
+		if types[record.Name] == nil {
+			types[record.Name] = map[string]bool{}
+		}
+		types[record.Name][record.Type] = true
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "; Zone %s exported from Constellix at %s\n", domain.Name, time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "$ORIGIN %s\n", origin)
+	renderSOA(&b, domain)
+	for i, record := range sorted {
+		zr, err := newZoneRecord(record, resolver)
+		if err != nil {
+			return fmt.Errorf("%s: %w", record.GetResourceID(), err)
+		}
+		name := record.Name
+		if name == "" {
+			name = "@"
+		}
+		first := i == 0 || sorted[i-1].Name != record.Name || sorted[i-1].Type != record.Type
+		switch {
+		case !record.Enabled:
+		case !first:
+			// Enabled records go first, so the first one is served
+			zr.comments = append(zr.comments, fmt.Sprintf("%s %s is already served by another record", name, record.Type))
+			zr.commentOut = true
+		case !isDefaultZoneRecord(record):
+			zr.comments = append(zr.comments, "no record is served by default, values of this record are served to all clients")
+		}
+		zoneRecords := []*zoneRecord{zr}
+		if record.Type == "ANAME" && !zr.commentOut {
+			cnameAllowed := record.Name != "" && len(types[record.Name]) == 1
+			zoneRecords = resolver.flattenANAME(zr, cnameAllowed)
+		}
+
+		b.WriteString("\n")
+		for _, zr := range zoneRecords {
+			for _, comment := range zr.comments {
+				fmt.Fprintf(&b, "; %s\n", comment)
+			}
+			prefix := ""
+			if zr.commentOut {
+				prefix = "; "
+			}
+			for _, rdata := range zr.rdata {
+				fmt.Fprintf(&b, "%s%s\t%d\tIN\t%s\t%s\n", prefix, name, record.TTL, zr.recordType, rdata)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// flattenANAME renders the ANAME record as CNAME, if it is allowed and there
+// is a single target, or as A and AAAA records of the targets, which are
+// resolved now
+func (r *activeValueResolver) flattenANAME(zr *zoneRecord, cnameAllowed bool) []*zoneRecord {
+	if len(zr.rdata) == 0 {
+		return []*zoneRecord{zr}
+	}
+	if cnameAllowed && len(zr.rdata) == 1 {
+		zr.recordType = "CNAME"
+		zr.comments = append(zr.comments, "ANAME is rendered as CNAME")
+		return []*zoneRecord{zr}
+	}
+
+	a := &zoneRecord{recordType: "A", comments: zr.comments}
+	aaaa := &zoneRecord{recordType: "AAAA"}
+	for _, target := range zr.rdata {
+		ips, err := r.lookupIP(target)
+		if err != nil {
+			zr.comments = append(zr.comments, fmt.Sprintf("unable to resolve ANAME target %s: %s", target, err))
+			zr.commentOut = true
+			return []*zoneRecord{zr}
+		}
+		for _, ip := range ips {
+			if ip.To4() != nil {
+				a.rdata = append(a.rdata, ip.String())
+			} else {
+				aaaa.rdata = append(aaaa.rdata, ip.String())
+			}
+		}
+	}
+	a.comments = append(a.comments, fmt.Sprintf(
+		"ANAME to %s is flattened to A and AAAA records of the target resolved at export", strings.Join(zr.rdata, ", "),
+	))
+	return []*zoneRecord{a, aaaa}
+}
+
+// renderSOA writes SOA and apex NS records of the domain. Constellix defaults
+// are used for SOA fields which are not available
+func renderSOA(b *strings.Builder, domain *DNSDomain) {
+	soa := DNSDomainSOA{Refresh: 43200, Retry: 3600, Expire: 1209600, NegativeCache: 180, TTL: 86400}
+	if domain.SOA != nil {
+		soa = *domain.SOA
+	}
+	if soa.PrimaryNameserver == "" && len(domain.Nameservers) > 0 {
+		soa.PrimaryNameserver = domain.Nameservers[0]
+	}
+	if soa.Email == "" {
+		soa.Email = "hostmaster." + domain.Name
+	}
+	if soa.Serial == 0 {
+		soa.Serial = int(time.Now().UTC().Unix())
+	}
+	email := strings.Replace(soa.Email, "@", ".", 1)
+	fmt.Fprintf(
+		b, "@\t%d\tIN\tSOA\t%s %s (\n\t\t%d ; serial\n\t\t%d ; refresh\n\t\t%d ; retry\n\t\t%d ; expire\n\t\t%d ) ; negative cache\n",
+		soa.TTL, absoluteName(soa.PrimaryNameserver), absoluteName(email),
+		soa.Serial, soa.Refresh, soa.Retry, soa.Expire, soa.NegativeCache,
+	)
+	for _, ns := range domain.Nameservers {
+		fmt.Fprintf(b, "@\t%d\tIN\tNS\t%s\n", soa.TTL, absoluteName(ns))
+	}
+}
+
+// isDefaultZoneRecord checks if the record is served to all clients
+func isDefaultZoneRecord(record *DNSRecord) bool {
+	return record.Enabled && (record.Region == "" || record.Region == "default") && record.GeoProximity == nil
+}
+
+// zoneRecordRank orders records of the same name and type: records served to
+// all clients, then records served to some clients and disabled records
+func zoneRecordRank(record *DNSRecord) int {
+	switch {
+	case isDefaultZoneRecord(record):
+		return 0
+	case record.Enabled:
+		return 1
+	}
+	return 2
+}
+
+func newZoneRecord(record *DNSRecord, resolver *activeValueResolver) (*zoneRecord, error) {
+	zr := &zoneRecord{recordType: record.Type}
+	if !record.Enabled {
+		zr.comments = append(zr.comments, "record is disabled in Constellix")
+		zr.commentOut = true
+	}
+	if record.Region != "" && record.Region != "default" {
+		zr.comments = append(zr.comments, fmt.Sprintf("served only in GTD region %s", record.Region))
+	}
+	if record.GeoProximity != nil {
+		zr.comments = append(zr.comments, fmt.Sprintf("served only to clients near GeoProximity %v", record.GeoProximity))
+	}
+	if record.IPFilter != nil {
+		zr.comments = append(zr.comments, fmt.Sprintf("served only to clients matching IP filter %v", record.IPFilter))
+	}
+
+	switch value := record.Value.(type) {
+	case []*DNSStandardItemValue:
+		for _, item := range value {
+			if item.Enabled {
+				zr.rdata = append(zr.rdata, formatStandardRData(record.Type, item.Value))
+			}
+		}
+	case *DNSFailoverValue:
+		values := make([]string, len(value.Values))
+		for i, item := range value.Values {
+			values[i] = item.Value
+		}
+		zr.comments = append(zr.comments, fmt.Sprintf(
+			"failover (%s) between %s, currently served value is shown", value.Mode, strings.Join(values, ", "),
+		))
+		for _, item := range resolver.activeFailoverValues(value.Values, true) {
+			zr.rdata = append(zr.rdata, formatStandardRData(record.Type, item))
+		}
+	case []*DNSFailoverItemValue:
+		values := make([]string, len(value))
+		for i, item := range value {
+			values[i] = item.Value
+		}
+		zr.comments = append(zr.comments, fmt.Sprintf(
+			"roundrobin-failover between %s, currently served values are shown", strings.Join(values, ", "),
+		))
+		for _, item := range resolver.activeFailoverValues(value, false) {
+			zr.rdata = append(zr.rdata, formatStandardRData(record.Type, item))
+		}
+	case []int:
+		names, active, err := resolver.activePoolValues(value)
+		if err != nil {
+			return nil, err
+		}
+		zr.comments = append(zr.comments, fmt.Sprintf(
+			"pools %s, currently served values are shown", strings.Join(names, ", "),
+		))
+		for _, item := range active {
+			zr.rdata = append(zr.rdata, formatStandardRData(record.Type, item))
+		}
+	case []*DNSMXStandardItemValue:
+		for _, item := range value {
+			if item.Enabled {
+				zr.rdata = append(zr.rdata, fmt.Sprintf("%d %s", item.Priority, absoluteName(item.Server)))
+			}
+		}
+	case []*DNSSRVStandardItemValue:
+		for _, item := range value {
+			if item.Enabled {
+				zr.rdata = append(zr.rdata, fmt.Sprintf(
+					"%d %d %d %s", item.Priority, item.Weight, item.Port, absoluteName(item.Host),
+				))
+			}
+		}
+	case []*DNSCAAStandardItemValue:
+		for _, item := range value {
+			if item.Enabled {
+				zr.rdata = append(zr.rdata, fmt.Sprintf("%d %s %s", item.Flag, item.Tag, quoteZoneString(item.Data)))
+			}
+		}
+	case []*DNSNAPTRStandardItemValue:
+		for _, item := range value {
+			if item.Enabled {
+				replacement := item.Replacement
+				if replacement != "." {
+					replacement = absoluteName(replacement)
+				}
+				zr.rdata = append(zr.rdata, fmt.Sprintf(
+					"%d %d %s %s %s %s", item.Order, item.Preference, quoteZoneString(item.Flags),
+					quoteZoneString(item.Service), quoteZoneString(item.RegularExpression), replacement,
+				))
+			}
+		}
+	case []*DNSCERTStandardItemValue:
+		for _, item := range value {
+			if item.Enabled {
+				zr.rdata = append(zr.rdata, fmt.Sprintf(
+					"%d %d %d %s", item.CertificateType, item.KeyTag, item.Algorithm, item.Certificate,
+				))
+			}
+		}
+	case []*DNSHINFOStandardItemValue:
+		for _, item := range value {
+			if item.Enabled {
+				zr.rdata = append(zr.rdata, quoteZoneString(item.CPU)+" "+quoteZoneString(item.OS))
+			}
+		}
+	case []*DNSRPStandardItemValue:
+		for _, item := range value {
+			if item.Enabled {
+				zr.rdata = append(zr.rdata, absoluteName(item.Mailbox)+" "+absoluteName(item.TXT))
+			}
+		}
+	case *DNSHTTPStandardItemValue:
+		zr.comments = append(zr.comments, fmt.Sprintf(
+			"HTTP redirect (%s) to %s, served by Constellix redirect servers", value.RedirectType, value.URL,
+		))
+		zr.rdata = append(zr.rdata, quoteZoneString(value.URL))
+		zr.commentOut = true
+	default:
+		return nil, fmt.Errorf("unsupported value %T", record.Value)
+	}
+
+	if record.Type == "CNAME" && len(zr.rdata) > 1 {
+		zr.rdata = zr.rdata[:1]
+	}
+	if len(zr.rdata) == 0 {
+		zr.comments = append(zr.comments, fmt.Sprintf("%s %s has no enabled values", record.Type, record.Name))
+	}
+	return zr, nil
+}
+
+// formatStandardRData formats a value of a record with a single field
+func formatStandardRData(recordType string, value string) string {
+	switch recordType {
+	case "TXT", "SPF":
+		return quoteZoneString(value)
+	case "CNAME", "ANAME", "NS", "PTR":
+		return absoluteName(value)
+	}
+	return value
+}
+
+// quoteZoneString quotes the value, splitting it into multiple strings if it
+// is longer than 255 characters
+func quoteZoneString(value string) string {
+	var parts []string
+	for {
+		chunk := value
+		if len(chunk) > maxZoneStringLength {
+			chunk = chunk[:maxZoneStringLength]
+		}
+		value = value[len(chunk):]
+		chunk = strings.ReplaceAll(chunk, `\`, `\\`)
+		chunk = strings.ReplaceAll(chunk, `"`, `\"`)
+		parts = append(parts, `"`+chunk+`"`)
+		if value == "" {
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// absoluteName adds the trailing dot to the domain name
+func absoluteName(name string) string {
+	if name == "" || strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_renderBINDZone(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/http":
+			w.Write([]byte(`[{"id":1,"name":"primary"},{"id":2,"name":"secondary"}]`))
+		case "/http/1/status":
+			w.Write([]byte(`{"status":"DOWN"}`))
+		case "/http/2/status":
+			w.Write([]byte(`{"status":"UP"}`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+	originalSonarRESTAPIBaseURL := sonarRESTAPIBaseURL
+	originalGetPools := GetPools
+	resetCache()
+	defer func() {
+		sonarRESTAPIBaseURL = originalSonarRESTAPIBaseURL
+		GetPools = originalGetPools
+		resetCache()
+	}()
+	sonarRESTAPIBaseURL = ts.URL
+	GetPools = func() ([]*Pool, error) {
+		return []*Pool{{ID: 7, Name: "web", Type: "A", Values: []*PoolValue{
+			{Value: "192.0.2.10", Enabled: true, SonarCheckID: 1},
+			{Value: "192.0.2.11", Enabled: true, SonarCheckID: 2},
+			{Value: "192.0.2.12", Enabled: false},
+		}}}, nil
+	}
+
+	data := `[
+{"name":"","type":"MX","ttl":300,"mode":"standard","region":"default","enabled":true,
+ "value":[{"server":"mail.example.com","priority":10,"enabled":true}]},
+{"name":"","type":"TXT","ttl":300,"mode":"standard","region":"default","enabled":true,
+ "value":[{"value":"v=spf1 \"quoted\" ~all","enabled":true},{"value":"disabled","enabled":false}]},
+{"name":"www","type":"A","ttl":60,"mode":"failover","region":"default","enabled":true,
+ "value":{"mode":"normal","enabled":true,"values":[
+  {"value":"192.0.2.1","order":1,"sonarCheckId":1,"enabled":true},
+  {"value":"192.0.2.2","order":2,"sonarCheckId":2,"enabled":true}]}},
+{"name":"www","type":"A","ttl":60,"mode":"standard","region":"europe","enabled":true,
+ "value":[{"value":"192.0.2.3","enabled":true}]},
+{"name":"pool","type":"A","ttl":60,"mode":"pools","region":"default","enabled":true,"value":[7]},
+{"name":"go","type":"HTTP","ttl":60,"mode":"standard","region":"default","enabled":true,
+ "value":{"url":"https://example.org","redirectType":"301","hard":true}},
+{"name":"geo","type":"A","ttl":60,"mode":"standard","region":"default","enabled":true,"geoproximity":{"id":5},
+ "value":[{"value":"192.0.2.4","enabled":true}]},
+{"name":"gtd","type":"A","ttl":60,"mode":"standard","region":"europe","enabled":true,
+ "value":[{"value":"192.0.2.5","enabled":true}]},
+{"name":"gtd","type":"A","ttl":60,"mode":"standard","region":"asia","enabled":true,
+ "value":[{"value":"192.0.2.6","enabled":true}]},
+{"name":"","type":"ANAME","ttl":60,"mode":"standard","region":"default","enabled":true,
+ "value":[{"value":"lb.example.net","enabled":true}]},
+{"name":"alias","type":"ANAME","ttl":60,"mode":"standard","region":"default","enabled":true,
+ "value":[{"value":"lb.example.net","enabled":true}]}
+]`
+	var records []*DNSRecord
+	err := json.Unmarshal([]byte(data), &records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	domain := &DNSDomain{Name: "example.com", Nameservers: []string{"ns11.constellix.com", "ns21.constellix.com"}}
+
+	resolver := newActiveValueResolver()
+	resolver.lookupIP = func(host string) ([]net.IP, error) {
+		if host != "lb.example.net." {
+			t.Errorf("unexpected lookup of %s", host)
+		}
+		return []net.IP{net.ParseIP("192.0.2.20"), net.ParseIP("2001:db8::20")}, nil
+	}
+
+	var b strings.Builder
+	err = renderBINDZone(&b, domain, records, resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zone := b.String()
+	for _, line := range []string{
+		"$ORIGIN example.com.",
+		"@\t86400\tIN\tNS\tns11.constellix.com.",
+		"@\t300\tIN\tMX\t10 mail.example.com.",
+		"@\t300\tIN\tTXT\t\"v=spf1 \\\"quoted\\\" ~all\"",
+		"; failover (normal) between 192.0.2.1, 192.0.2.2, currently served value is shown",
+		"www\t60\tIN\tA\t192.0.2.2",
+		"; served only in GTD region europe",
+		"; www\t60\tIN\tA\t192.0.2.3",
+		"; pools web, currently served values are shown",
+		"pool\t60\tIN\tA\t192.0.2.11",
+		"; go\t60\tIN\tHTTP\t\"https://example.org\"",
+		// Names without records served by default are not dropped
+		"; no record is served by default, values of this record are served to all clients",
+		"geo\t60\tIN\tA\t192.0.2.4",
+		"gtd\t60\tIN\tA\t192.0.2.5",
+		"; gtd A is already served by another record",
+		"; gtd\t60\tIN\tA\t192.0.2.6",
+		"; ANAME to lb.example.net. is flattened to A and AAAA records of the target resolved at export",
+		"@\t60\tIN\tA\t192.0.2.20",
+		"@\t60\tIN\tAAAA\t2001:db8::20",
+		"alias\t60\tIN\tCNAME\tlb.example.net.",
+	} {
+		if !strings.Contains(zone, line+"\n") {
+			t.Errorf("expected line %q in zone:\n%s", line, zone)
+		}
+	}
+	for _, value := range []string{"disabled", "192.0.2.1\n", "192.0.2.10", "192.0.2.12"} {
+		if strings.Contains(zone, value) {
+			t.Errorf("unexpected value %q in zone:\n%s", value, zone)
+		}
+	}
+
+	// Exported zone is a valid zone file
+	imported, issues, err := parseBINDZone(strings.NewReader(zone), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 9 {
+		t.Errorf("expected 9 records, got %d", len(imported))
+	}
+	if len(issues) != 3 {
+		t.Errorf("expected SOA and apex NS issues, got %d", len(issues))
+	}
+}
+
+func Test_quoteZoneString(t *testing.T) {
+	value := strings.Repeat("a", 300)
+	expected := `"` + strings.Repeat("a", 255) + `" "` + strings.Repeat("a", 45) + `"`
+	if quoteZoneString(value) != expected {
+		t.Errorf("unexpected quoted string %s", quoteZoneString(value))
+	}
+}
+
+func Test_activeValueResolver_isDown_all_check_types(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/icmp":
+			w.Write([]byte(`[{"id":3,"name":"ping"}]`))
+		case "/ssl":
+			w.Write([]byte(`[{"id":4,"name":"cert"}]`))
+		case "/icmp/3/status", "/ssl/4/status":
+			w.Write([]byte(`{"status":"DOWN"}`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+	originalSonarRESTAPIBaseURL := sonarRESTAPIBaseURL
+	resetCache()
+	defer func() {
+		sonarRESTAPIBaseURL = originalSonarRESTAPIBaseURL
+		resetCache()
+	}()
+	sonarRESTAPIBaseURL = ts.URL
+
+	resolver := newActiveValueResolver()
+	for _, id := range []int{3, 4} {
+		if !resolver.isDown(id) {
+			t.Errorf("want check %d to be down", id)
+		}
+	}
+}
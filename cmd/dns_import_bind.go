@@ -0,0 +1,607 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Mnemonics of CERT record certificate types (RFC 4398)
+var certTypeMnemonics = map[string]int{
+	"PKIX": 1, "SPKI": 2, "PGP": 3, "IPKIX": 4, "ISPKI": 5, "IPGP": 6,
+	"ACPKIX": 7, "IACPKIX": 8, "URI": 253, "OID": 254,
+}
+
+// zoneToken is a single field of a zone file entry
+type zoneToken struct {
+	text   string
+	quoted bool
+}
+
+// zoneEntry is a logical line of a zone file. Entries enclosed in parentheses
+// may span multiple lines, line is the number of the first one
+type zoneEntry struct {
+	line int
+	// The entry starts with a blank, so the owner of the previous entry is used
+	blankOwner bool
+	tokens     []zoneToken
+}
+
+// readZoneEntries splits a zone file into entries, removing comments and
+// joining lines enclosed in parentheses
+func readZoneEntries(r io.Reader) ([]*zoneEntry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*zoneEntry
+	line := 1
+	depth := 0
+	entry := &zoneEntry{line: line}
+	var token bytes.Buffer
+	inToken := false
+	startOfLine := true
+
+	endToken := func(quoted bool) {
+		if inToken || quoted {
+			entry.tokens = append(entry.tokens, zoneToken{text: token.String(), quoted: quoted})
+		}
+		token.Reset()
+		inToken = false
+	}
+	endEntry := func() {
+		if len(entry.tokens) > 0 {
+			entries = append(entries, entry)
+		}
+		entry = &zoneEntry{line: line}
+		startOfLine = true
+	}
+	// readEscape decodes \X and \DDD sequences starting at data[i] (the
+	// backslash) and returns the index of the last consumed byte
+	readEscape := func(i int) (int, error) {
+		if i+1 >= len(data) {
+			return i, fmt.Errorf("line %d: unterminated escape sequence", line)
+		}
+		if i+3 < len(data) && isDigit(data[i+1]) && isDigit(data[i+2]) && isDigit(data[i+3]) {
+			code, _ := strconv.Atoi(string(data[i+1 : i+4]))
+			if code > 255 {
+				return i, fmt.Errorf("line %d: invalid escape sequence \\%s", line, data[i+1:i+4])
+			}
+			token.WriteByte(byte(code))
+			return i + 3, nil
+		}
+		token.WriteByte(data[i+1])
+		return i + 1, nil
+	}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if startOfLine && depth == 0 && len(entry.tokens) == 0 && !inToken {
+			entry.line = line
+			entry.blankOwner = c == ' ' || c == '\t'
+		}
+		startOfLine = false
+		switch {
+		case c == ';':
+			endToken(false)
+			for i+1 < len(data) && data[i+1] != '\n' {
+				i++
+			}
+		case c == '"':
+			endToken(false)
+			start := line
+			closed := false
+			for i++; i < len(data); i++ {
+				if data[i] == '"' {
+					closed = true
+					break
+				}
+				if data[i] == '\\' {
+					i, err = readEscape(i)
+					if err != nil {
+						return nil, err
+					}
+					continue
+				}
+				if data[i] == '\n' {
+					line++
+				}
+				token.WriteByte(data[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("line %d: unterminated quoted string", start)
+			}
+			endToken(true)
+		case c == '\\':
+			inToken = true
+			i, err = readEscape(i)
+			if err != nil {
+				return nil, err
+			}
+		case c == '(':
+			endToken(false)
+			depth++
+		case c == ')':
+			endToken(false)
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("line %d: unbalanced parentheses", line)
+			}
+		case c == '\n':
+			endToken(false)
+			line++
+			if depth == 0 {
+				endEntry()
+			}
+		case c == ' ' || c == '\t' || c == '\r':
+			endToken(false)
+		default:
+			inToken = true
+			token.WriteByte(c)
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("line %d: unbalanced parentheses", entry.line)
+	}
+	endToken(false)
+	endEntry()
+	return entries, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parseZoneTTL parses a TTL in seconds or with BIND units (e.g. 1h30m)
+func parseZoneTTL(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	if ttl, err := strconv.Atoi(s); err == nil {
+		return ttl, ttl >= 0
+	}
+	units := map[byte]int{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}
+	ttl := 0
+	number := ""
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isDigit(c) {
+			number += string(c)
+			continue
+		}
+		unit, ok := units[c|0x20]
+		if !ok || number == "" {
+			return 0, false
+		}
+		n, _ := strconv.Atoi(number)
+		ttl += n * unit
+		number = ""
+	}
+	if number != "" {
+		return 0, false
+	}
+	return ttl, true
+}
+
+// isZoneClass checks if the token is a DNS class
+func isZoneClass(s string) bool {
+	switch strings.ToUpper(s) {
+	case "IN", "CH", "CS", "HS":
+		return true
+	}
+	return false
+}
+
+// bindZoneParser converts resource records of a zone file to DNS records of
+// the domain. Resource records with the same name and type are merged into
+// a single DNS record with multiple values
+type bindZoneParser struct {
+	domain     string
+	origin     string
+	defaultTTL int
+	lastOwner  string
+	lastTTL    int
+	records    []*DNSRecord
+	recordsMap map[string]*DNSRecord
+	issues     []*importIssue
+}
+
+// parseBINDZone parses a zone file in RFC 1035 format and returns DNS
+// records of the domain in standard mode. Resource records which can't be
+// represented in Constellix are returned as issues
+func parseBINDZone(r io.Reader, domain string) ([]*DNSRecord, []*importIssue, error) {
+	entries, err := readZoneEntries(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	p := &bindZoneParser{
+		domain:     domain,
+		origin:     domain + ".",
+		defaultTTL: -1,
+		lastTTL:    -1,
+		recordsMap: map[string]*DNSRecord{},
+	}
+	for _, entry := range entries {
+		err = p.parseEntry(entry)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", entry.line, err)
+		}
+	}
+	return p.records, p.issues, nil
+}
+
+func (p *bindZoneParser) parseEntry(entry *zoneEntry) error {
+	tokens := entry.tokens
+	if strings.HasPrefix(tokens[0].text, "$") && !tokens[0].quoted {
+		return p.parseDirective(tokens)
+	}
+
+	owner := p.lastOwner
+	if !entry.blankOwner {
+		owner = p.qualifyName(tokens[0].text)
+		tokens = tokens[1:]
+	}
+	if owner == "" {
+		return fmt.Errorf("owner name is not defined")
+	}
+	p.lastOwner = owner
+
+	ttl := -1
+	class := "IN"
+	recordType := ""
+	for len(tokens) > 0 && recordType == "" {
+		text := tokens[0].text
+		tokens = tokens[1:]
+		if value, ok := parseZoneTTL(text); ok && ttl < 0 {
+			ttl = value
+		} else if isZoneClass(text) {
+			class = strings.ToUpper(text)
+		} else {
+			recordType = strings.ToUpper(text)
+		}
+	}
+	if recordType == "" {
+		return fmt.Errorf("record type is not defined")
+	}
+	if ttl < 0 {
+		// RFC 2308: $TTL is used if defined, otherwise the last explicit TTL
+		ttl = p.defaultTTL
+		if ttl < 0 {
+			ttl = p.lastTTL
+		}
+		if ttl < 0 && recordType != "SOA" {
+			return fmt.Errorf("TTL is not defined, use $TTL directive")
+		}
+	} else {
+		p.lastTTL = ttl
+	}
+
+	issue := func(reason string, args ...interface{}) error {
+		p.issues = append(p.issues, &importIssue{
+			Location: fmt.Sprintf("line %d", entry.line),
+			Record:   fmt.Sprintf("%s %s", owner, recordType),
+			Reason:   fmt.Sprintf(reason, args...),
+		})
+		return nil
+	}
+
+	if class != "IN" {
+		return issue("class %s is not supported", class)
+	}
+	name, ok := p.relativeName(owner)
+	if !ok {
+		return issue("name is outside of domain %s", p.domain)
+	}
+
+	fields := make([]string, len(tokens))
+	for i, token := range tokens {
+		fields[i] = token.text
+	}
+	var value interface{}
+	var err error
+	switch recordType {
+	case "SOA":
+		if ttl < 0 && len(fields) == 7 {
+			// RFC 1035: minimum field is used when TTL is not defined
+			if minimum, ok := parseZoneTTL(fields[6]); ok {
+				p.lastTTL = minimum
+			}
+		}
+		return issue("SOA record is managed by Constellix")
+	case "NS":
+		if name == "" {
+			return issue("apex NS records are managed by Constellix")
+		}
+		value, err = p.parseTarget(fields)
+	case "A", "AAAA":
+		value, err = parseStandardValue(fields)
+	case "ANAME", "CNAME", "PTR":
+		value, err = p.parseTarget(fields)
+	case "TXT", "SPF":
+		if len(fields) == 0 {
+			err = fmt.Errorf("expected at least 1 field")
+		}
+		value = []*DNSStandardItemValue{{Value: joinTXTStrings(tokens, issue), Enabled: true}}
+	case "MX":
+		value, err = p.parseMX(fields)
+	case "SRV":
+		value, err = p.parseSRV(fields)
+	case "CAA":
+		value, err = parseCAA(fields)
+	case "NAPTR":
+		value, err = p.parseNAPTR(fields)
+	case "CERT":
+		value, err = parseCERT(fields)
+	case "HINFO":
+		if len(fields) != 2 {
+			err = fmt.Errorf("expected 2 fields, got %d", len(fields))
+		}
+		value = []*DNSHINFOStandardItemValue{{CPU: fieldAt(fields, 0), OS: fieldAt(fields, 1), Enabled: true}}
+	case "RP":
+		if len(fields) != 2 {
+			err = fmt.Errorf("expected 2 fields, got %d", len(fields))
+		}
+		value = []*DNSRPStandardItemValue{{
+			Mailbox: p.qualifyName(fieldAt(fields, 0)), TXT: p.qualifyName(fieldAt(fields, 1)), Enabled: true,
+		}}
+	default:
+		return issue("record type %s is not supported by Constellix", recordType)
+	}
+	if err != nil {
+		return issue("invalid data: %s", err)
+	}
+
+	record := &DNSRecord{
+		Name:    name,
+		Type:    recordType,
+		TTL:     ttl,
+		Mode:    "standard",
+		Region:  "default",
+		Enabled: true,
+		Value:   value,
+	}
+	err = validateDNSRecordValue(record)
+	if err != nil {
+		return issue("%s", err)
+	}
+
+	key := record.GetResourceID()
+	existing, ok := p.recordsMap[key]
+	if !ok {
+		p.recordsMap[key] = record
+		p.records = append(p.records, record)
+		return nil
+	}
+	if recordType == "CNAME" || recordType == "ANAME" {
+		return issue("only a single %s record is allowed for a name", recordType)
+	}
+	if existing.TTL != ttl {
+		issue("TTL %d differs from TTL %d of the record set, %d is used", ttl, existing.TTL, existing.TTL)
+	}
+	existing.Value = appendDNSRecordValue(existing.Value, value)
+	return nil
+}
+
+// joinTXTStrings joins character-strings of TXT records. Quoted strings are
+// joined without a separator, because long values are split into several
+// strings to fit the length limit. Blanks between unquoted words are kept as
+// a space, which is reported as an issue
+func joinTXTStrings(tokens []zoneToken, issue func(string, ...interface{}) error) string {
+	var value strings.Builder
+	unquoted := false
+	for i, token := range tokens {
+		if i > 0 && (!token.quoted || !tokens[i-1].quoted) {
+			value.WriteString(" ")
+			unquoted = true
+		}
+		value.WriteString(token.text)
+	}
+	if unquoted {
+		issue("unquoted strings are joined with spaces, quote the value to make it explicit")
+	}
+	return value.String()
+}
+
+// parseDirective handles $ORIGIN and $TTL directives
+func (p *bindZoneParser) parseDirective(tokens []zoneToken) error {
+	directive := strings.ToUpper(tokens[0].text)
+	switch directive {
+	case "$ORIGIN":
+		if len(tokens) != 2 {
+			return fmt.Errorf("$ORIGIN expects a domain name")
+		}
+		p.origin = p.qualifyName(tokens[1].text)
+	case "$TTL":
+		if len(tokens) != 2 {
+			return fmt.Errorf("$TTL expects a TTL value")
+		}
+		ttl, ok := parseZoneTTL(tokens[1].text)
+		if !ok {
+			return fmt.Errorf("invalid TTL %q", tokens[1].text)
+		}
+		p.defaultTTL = ttl
+	default:
+		return fmt.Errorf("%s directive is not supported", tokens[0].text)
+	}
+	return nil
+}
+
+// qualifyName returns the absolute name with trailing dot
+func (p *bindZoneParser) qualifyName(name string) string {
+	switch {
+	case name == "@":
+		return p.origin
+	case strings.HasSuffix(name, "."):
+		return strings.ToLower(name)
+	default:
+		return strings.ToLower(name) + "." + p.origin
+	}
+}
+
+// relativeName returns the name relative to the domain. Apex is an empty
+// string
+func (p *bindZoneParser) relativeName(fqdn string) (string, bool) {
+	fqdn = strings.TrimSuffix(fqdn, ".")
+	if fqdn == p.domain {
+		return "", true
+	}
+	name, ok := strings.CutSuffix(fqdn, "."+p.domain)
+	return name, ok
+}
+
+func (p *bindZoneParser) parseTarget(fields []string) ([]*DNSStandardItemValue, error) {
+	if len(fields) != 1 {
+		return nil, fmt.Errorf("expected 1 field, got %d", len(fields))
+	}
+	return []*DNSStandardItemValue{{Value: p.qualifyName(fields[0]), Enabled: true}}, nil
+}
+
+func parseStandardValue(fields []string) ([]*DNSStandardItemValue, error) {
+	if len(fields) != 1 {
+		return nil, fmt.Errorf("expected 1 field, got %d", len(fields))
+	}
+	return []*DNSStandardItemValue{{Value: fields[0], Enabled: true}}, nil
+}
+
+func (p *bindZoneParser) parseMX(fields []string) ([]*DNSMXStandardItemValue, error) {
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("expected 2 fields, got %d", len(fields))
+	}
+	priority, err := parseUint(fields[0], 65535)
+	if err != nil {
+		return nil, err
+	}
+	return []*DNSMXStandardItemValue{{Server: p.qualifyName(fields[1]), Priority: priority, Enabled: true}}, nil
+}
+
+func (p *bindZoneParser) parseSRV(fields []string) ([]*DNSSRVStandardItemValue, error) {
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("expected 4 fields, got %d", len(fields))
+	}
+	numbers, err := parseUints(fields[:3], 65535)
+	if err != nil {
+		return nil, err
+	}
+	return []*DNSSRVStandardItemValue{{
+		Priority: numbers[0],
+		Weight:   numbers[1],
+		Port:     numbers[2],
+		Host:     p.qualifyName(fields[3]),
+		Enabled:  true,
+	}}, nil
+}
+
+func parseCAA(fields []string) ([]*DNSCAAStandardItemValue, error) {
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("expected 3 fields, got %d", len(fields))
+	}
+	flag, err := parseUint(fields[0], 255)
+	if err != nil {
+		return nil, err
+	}
+	return []*DNSCAAStandardItemValue{{Flag: flag, Tag: strings.ToLower(fields[1]), Data: fields[2], Enabled: true}}, nil
+}
+
+func (p *bindZoneParser) parseNAPTR(fields []string) ([]*DNSNAPTRStandardItemValue, error) {
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("expected 6 fields, got %d", len(fields))
+	}
+	numbers, err := parseUints(fields[:2], 65535)
+	if err != nil {
+		return nil, err
+	}
+	replacement := fields[5]
+	if replacement != "." {
+		replacement = p.qualifyName(replacement)
+	}
+	return []*DNSNAPTRStandardItemValue{{
+		Order:             numbers[0],
+		Preference:        numbers[1],
+		Flags:             fields[2],
+		Service:           fields[3],
+		RegularExpression: fields[4],
+		Replacement:       replacement,
+		Enabled:           true,
+	}}, nil
+}
+
+func parseCERT(fields []string) ([]*DNSCERTStandardItemValue, error) {
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("expected at least 4 fields, got %d", len(fields))
+	}
+	certType, ok := certTypeMnemonics[strings.ToUpper(fields[0])]
+	if !ok {
+		var err error
+		certType, err = parseUint(fields[0], 65535)
+		if err != nil {
+			return nil, err
+		}
+	}
+	numbers, err := parseUints(fields[1:3], 65535)
+	if err != nil {
+		return nil, err
+	}
+	return []*DNSCERTStandardItemValue{{
+		CertificateType: certType,
+		KeyTag:          numbers[0],
+		Algorithm:       numbers[1],
+		// Base64 data may be split into multiple fields
+		Certificate: strings.Join(fields[3:], ""),
+		Enabled:     true,
+	}}, nil
+}
+
+func parseUint(s string, max int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > max {
+		return 0, fmt.Errorf("invalid number %q, expected a value between 0 and %d", s, max)
+	}
+	return n, nil
+}
+
+func parseUints(fields []string, max int) ([]int, error) {
+	numbers := make([]int, len(fields))
+	for i, field := range fields {
+		n, err := parseUint(field, max)
+		if err != nil {
+			return nil, err
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
+
+func fieldAt(fields []string, idx int) string {
+	if idx < len(fields) {
+		return fields[idx]
+	}
+	return ""
+}
+
+// appendDNSRecordValue appends values of standard mode records of the same
+// type
+func appendDNSRecordValue(existing interface{}, value interface{}) interface{} {
+	switch v := existing.(type) {
+	case []*DNSStandardItemValue:
+		return append(v, value.([]*DNSStandardItemValue)...)
+	case []*DNSMXStandardItemValue:
+		return append(v, value.([]*DNSMXStandardItemValue)...)
+	case []*DNSSRVStandardItemValue:
+		return append(v, value.([]*DNSSRVStandardItemValue)...)
+	case []*DNSCAAStandardItemValue:
+		return append(v, value.([]*DNSCAAStandardItemValue)...)
+	case []*DNSNAPTRStandardItemValue:
+		return append(v, value.([]*DNSNAPTRStandardItemValue)...)
+	case []*DNSCERTStandardItemValue:
+		return append(v, value.([]*DNSCERTStandardItemValue)...)
+	case []*DNSHINFOStandardItemValue:
+		return append(v, value.([]*DNSHINFOStandardItemValue)...)
+	case []*DNSRPStandardItemValue:
+		return append(v, value.([]*DNSRPStandardItemValue)...)
+	}
+	return existing
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+const testZoneFile = `
+$ORIGIN example.com.
+$TTL 1h
+@	IN	SOA	ns1.example.com. hostmaster.example.com. (
+		2024010101 ; serial
+		7200       ; refresh
+		3600       ; retry
+		1209600    ; expire
+		300 )      ; minimum
+	IN	NS	ns1.example.com.
+	IN	MX	10 mail
+	IN	MX	20 mail.backup.net.
+	IN	TXT	"v=spf1 include:_spf.example.net " "~all"
+	IN	CAA	0 issue "letsencrypt.org"
+www	300	IN	A	192.0.2.1
+	IN	300	A	192.0.2.2
+	AAAA	2001:db8::1
+api	CNAME	www
+_sip._tcp	SRV	10 60 5060 sip
+dev	NS	ns1.dev
+*.wild	TXT	"quote \" inside" ; comment
+$ORIGIN sub.example.com.
+host	A	192.0.2.3
+key	DNSKEY	257 3 8 AwEAAa==
+other.net.	A	192.0.2.4
+`
+
+func Test_parseBINDZone(t *testing.T) {
+	records, issues, err := parseBINDZone(strings.NewReader(testZoneFile), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedIDs := []string{
+		`MX "" (default, 0)`,
+		`TXT "" (default, 0)`,
+		`CAA "" (default, 0)`,
+		`A "www" (default, 0)`,
+		`AAAA "www" (default, 0)`,
+		`CNAME "api" (default, 0)`,
+		`SRV "_sip._tcp" (default, 0)`,
+		`NS "dev" (default, 0)`,
+		`TXT "*.wild" (default, 0)`,
+		`A "host.sub" (default, 0)`,
+	}
+	if len(records) != len(expectedIDs) {
+		t.Fatalf("expected %d records, got %d", len(expectedIDs), len(records))
+	}
+	for i, record := range records {
+		if record.GetResourceID() != expectedIDs[i] {
+			t.Errorf("expected %s, got %s", expectedIDs[i], record.GetResourceID())
+		}
+	}
+
+	mx := records[0].Value.([]*DNSMXStandardItemValue)
+	if len(mx) != 2 || mx[0].Server != "mail.example.com." || mx[1].Priority != 20 || records[0].TTL != 3600 {
+		t.Errorf("unexpected MX record: %+v", mx)
+	}
+	txt := records[1].Value.([]*DNSStandardItemValue)
+	if txt[0].Value != "v=spf1 include:_spf.example.net ~all" {
+		t.Errorf("unexpected TXT value %q", txt[0].Value)
+	}
+	a := records[3].Value.([]*DNSStandardItemValue)
+	if len(a) != 2 || records[3].TTL != 300 {
+		t.Errorf("unexpected A record: %+v", a)
+	}
+	// Last explicit TTL is not used when $TTL is defined
+	if records[4].TTL != 3600 {
+		t.Errorf("expected TTL 3600, got %d", records[4].TTL)
+	}
+	cname := records[5].Value.([]*DNSStandardItemValue)
+	if cname[0].Value != "www.example.com." {
+		t.Errorf("unexpected CNAME value %q", cname[0].Value)
+	}
+	srv := records[6].Value.([]*DNSSRVStandardItemValue)
+	if srv[0].Host != "sip.example.com." || srv[0].Port != 5060 || srv[0].Weight != 60 {
+		t.Errorf("unexpected SRV value %+v", srv[0])
+	}
+	wild := records[8].Value.([]*DNSStandardItemValue)
+	if wild[0].Value != `quote " inside` {
+		t.Errorf("unexpected TXT value %q", wild[0].Value)
+	}
+
+	expectedIssues := []string{
+		"example.com. SOA",
+		"example.com. NS",
+		"key.sub.example.com. DNSKEY",
+		"other.net. A",
+	}
+	if len(issues) != len(expectedIssues) {
+		t.Fatalf("expected %d issues, got %d", len(expectedIssues), len(issues))
+	}
+	for i, issue := range issues {
+		if issue.Record != expectedIssues[i] {
+			t.Errorf("expected issue for %s, got %s (%s)", expectedIssues[i], issue.Record, issue.Reason)
+		}
+	}
+	if issues[0].Location != "line 4" {
+		t.Errorf("expected line 4, got %s", issues[0].Location)
+	}
+}
+
+func Test_parseBINDZone_Errors(t *testing.T) {
+	for _, data := range []string{
+		"www A 192.0.2.1\n",
+		"$TTL 60\nwww TXT \"unterminated\n",
+		"$TTL 60\n@ SOA ns hostmaster ( 1 2 3 4 5\n",
+		"$INCLUDE other.zone\n",
+	} {
+		_, _, err := parseBINDZone(strings.NewReader(data), "example.com")
+		if err == nil {
+			t.Errorf("expected error for %q", data)
+		}
+	}
+}
+
+func Test_parseBINDZone_TXT(t *testing.T) {
+	data := `$TTL 60
+quoted	TXT	"foo" "bar"
+single	TXT	foo
+words	TXT	foo bar
+`
+	records, issues, err := parseBINDZone(strings.NewReader(data), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"foobar", "foo", "foo bar"}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records, got %d", len(expected), len(records))
+	}
+	for i, record := range records {
+		value := record.Value.([]*DNSStandardItemValue)[0].Value
+		if value != expected[i] {
+			t.Errorf("%s: expected %q, got %q", record.Name, expected[i], value)
+		}
+	}
+	// Unquoted words are reported, the value might have been meant as
+	// separate strings
+	if len(issues) != 1 || issues[0].Record != "words.example.com. TXT" {
+		t.Errorf("expected an issue for unquoted words, got %+v", issues)
+	}
+}
+
+func Test_writeImportedDNSRecords(t *testing.T) {
+	records, _, err := parseBINDZone(strings.NewReader(testZoneFile), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputFile := filepath.Join(t.TempDir(), "records.yaml")
+	err = writeImportedDNSRecords(records, outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Generated configuration is read as expected DNS records
+	var expected []*ExpectedDNSRecord
+	err = yaml.Unmarshal(data, &expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expected) != len(records) {
+		t.Fatalf("expected %d records, got %d", len(records), len(expected))
+	}
+	for i, item := range expected {
+		err = item.Validate()
+		if err != nil {
+			t.Error(err)
+		}
+		action, diffs, err := Compare(item, records[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if action != ActionOK {
+			t.Errorf("%s: expected no changes, got %v", item.GetResourceID(), diffs)
+		}
+	}
+}
@@ -0,0 +1,859 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// Default TTL of octoDNS records
+const octoDNSDefaultTTL = 3600
+
+// US states served by us-west GTD region, other states are served by us-east
+var usWestStates = []string{"AK", "AZ", "CA", "CO", "HI", "ID", "MT", "NM", "NV", "OR", "UT", "WA", "WY"}
+var usEastStates = []string{
+	"AL", "AR", "CT", "DC", "DE", "FL", "GA", "IA", "IL", "IN", "KS", "KY", "LA", "MA", "MD", "ME", "MI", "MN", "MO",
+	"MS", "NC", "ND", "NE", "NH", "NJ", "NY", "OH", "OK", "PA", "RI", "SC", "SD", "TN", "TX", "VA", "VT", "WI", "WV",
+}
+
+// gtdRegionGeos maps Constellix GTD regions to octoDNS geo codes which cover
+// them
+var gtdRegionGeos = map[string][]string{
+	"europe":        {"EU"},
+	"asia-pacific":  {"AS"},
+	"oceania":       {"OC"},
+	"south-america": {"SA"},
+	"us-east":       usStateGeos(usEastStates),
+	"us-west":       usStateGeos(usWestStates),
+}
+
+func usStateGeos(states []string) []string {
+	geos := make([]string, len(states))
+	for i, state := range states {
+		geos[i] = "NA-US-" + state
+	}
+	return geos
+}
+
+// Record types which support octoDNS geo and dynamic sections
+var octoDNSDynamicTypes = []string{"A", "AAAA", "CNAME"}
+
+// octoDNSRecord is a record of octoDNS zone configuration
+type octoDNSRecord struct {
+	Type    string                 `yaml:"type"`
+	TTL     int                    `yaml:"ttl,omitempty"`
+	Value   interface{}            `yaml:"value,omitempty"`
+	Values  []interface{}          `yaml:"values,omitempty"`
+	Geo     map[string][]string    `yaml:"geo,omitempty"`
+	Dynamic *octoDNSDynamic        `yaml:"dynamic,omitempty"`
+	Octodns map[string]interface{} `yaml:"octodns,omitempty"`
+}
+
+type octoDNSDynamic struct {
+	Pools map[string]*octoDNSPool `yaml:"pools"`
+	Rules []*octoDNSRule          `yaml:"rules"`
+}
+
+type octoDNSPool struct {
+	Fallback string              `yaml:"fallback,omitempty"`
+	Values   []*octoDNSPoolValue `yaml:"values"`
+}
+
+type octoDNSPoolValue struct {
+	Value  string `yaml:"value"`
+	Weight int    `yaml:"weight,omitempty"`
+	Status string `yaml:"status,omitempty"`
+}
+
+type octoDNSRule struct {
+	Geos    []string `yaml:"geos,omitempty"`
+	Subnets []string `yaml:"subnets,omitempty"`
+	Pool    string   `yaml:"pool"`
+}
+
+type octoDNSMXValue struct {
+	Exchange   string `yaml:"exchange"`
+	Preference int    `yaml:"preference"`
+}
+
+type octoDNSSRVValue struct {
+	Port     int    `yaml:"port"`
+	Priority int    `yaml:"priority"`
+	Target   string `yaml:"target"`
+	Weight   int    `yaml:"weight"`
+}
+
+type octoDNSCAAValue struct {
+	Flags int    `yaml:"flags"`
+	Tag   string `yaml:"tag"`
+	Value string `yaml:"value"`
+}
+
+type octoDNSNAPTRValue struct {
+	Flags       string `yaml:"flags"`
+	Order       int    `yaml:"order"`
+	Preference  int    `yaml:"preference"`
+	Regexp      string `yaml:"regexp"`
+	Replacement string `yaml:"replacement"`
+	Service     string `yaml:"service"`
+}
+
+func (r *octoDNSRecord) values() []interface{} {
+	if r.Values != nil {
+		return r.Values
+	}
+	if r.Value != nil {
+		return []interface{}{r.Value}
+	}
+	return nil
+}
+
+// poolChain returns the pool with all its fallback pools
+func (d *octoDNSDynamic) poolChain(name string) ([]*octoDNSPool, error) {
+	var chain []*octoDNSPool
+	visited := map[string]bool{}
+	for name != "" && !visited[name] {
+		visited[name] = true
+		pool, ok := d.Pools[name]
+		if !ok {
+			return nil, fmt.Errorf("pool %q is not defined", name)
+		}
+		chain = append(chain, pool)
+		name = pool.Fallback
+	}
+	return chain, nil
+}
+
+// geoRegion returns the GTD region which serves the octoDNS geo code
+func geoRegion(geo string) (string, bool) {
+	parts := strings.Split(strings.ToUpper(geo), "-")
+	switch parts[0] {
+	case "EU":
+		return "europe", true
+	case "AS":
+		return "asia-pacific", true
+	case "OC":
+		return "oceania", true
+	case "SA":
+		return "south-america", true
+	case "NA":
+		if len(parts) == 3 && parts[1] == "US" {
+			if slices.Contains(usWestStates, parts[2]) {
+				return "us-west", true
+			}
+			if slices.Contains(usEastStates, parts[2]) {
+				return "us-east", true
+			}
+		}
+	}
+	return "", false
+}
+
+// octoDNSConverter converts records between octoDNS and mech configuration
+// and collects issues for anything which can't be converted exactly
+type octoDNSConverter struct {
+	issues []*importIssue
+}
+
+func (c *octoDNSConverter) addIssue(location string, record string, reason string, args ...interface{}) {
+	c.issues = append(c.issues, &importIssue{
+		Location: location,
+		Record:   record,
+		Reason:   fmt.Sprintf(reason, args...),
+	})
+}
+
+// importOctoDNSZone converts octoDNS zone configuration to DNS records.
+// octoDNS geo and dynamic rules are converted to records in GTD regions,
+// pools with fallbacks are converted to failover and roundrobin-failover modes
+func importOctoDNSZone(data []byte) ([]*DNSRecord, []*importIssue, error) {
+	var root yaml.Node
+	err := yaml.Unmarshal(data, &root)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := &octoDNSConverter{}
+	if len(root.Content) == 0 {
+		return nil, nil, nil
+	}
+	zone := root.Content[0]
+	if zone.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("line %d: expected a map of record names", zone.Line)
+	}
+
+	var records []*DNSRecord
+	for i := 0; i+1 < len(zone.Content); i += 2 {
+		name := zone.Content[i].Value
+		node := zone.Content[i+1]
+		items := []*yaml.Node{node}
+		if node.Kind == yaml.SequenceNode {
+			items = node.Content
+		}
+		for _, item := range items {
+			var record octoDNSRecord
+			err = item.Decode(&record)
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: %w", item.Line, err)
+			}
+			records = append(records, c.importRecord(fmt.Sprintf("line %d", item.Line), name, &record)...)
+		}
+	}
+	return records, c.issues, nil
+}
+
+func (c *octoDNSConverter) importRecord(location string, name string, record *octoDNSRecord) []*DNSRecord {
+	recordType := strings.ToUpper(record.Type)
+	if recordType == "ALIAS" {
+		recordType = "ANAME"
+	}
+	recordID := fmt.Sprintf("%s %q", record.Type, name)
+	switch recordType {
+	case "A", "AAAA", "ANAME", "CNAME", "NS", "PTR", "TXT", "SPF", "MX", "SRV", "CAA", "NAPTR":
+	default:
+		c.addIssue(location, recordID, "record type %s is not supported by Constellix", record.Type)
+		return nil
+	}
+	if recordType == "NS" && name == "" {
+		c.addIssue(location, recordID, "apex NS records are managed by Constellix")
+		return nil
+	}
+	ttl := record.TTL
+	if ttl == 0 {
+		ttl = octoDNSDefaultTTL
+	}
+	newRecord := func(region string, mode string, value interface{}) *DNSRecord {
+		return &DNSRecord{
+			Name:    name,
+			Type:    recordType,
+			TTL:     ttl,
+			Mode:    mode,
+			Region:  region,
+			Enabled: true,
+			Value:   value,
+		}
+	}
+
+	defaultValue, err := importOctoDNSValues(recordType, record.values())
+	if err != nil {
+		c.addIssue(location, recordID, "invalid value: %s", err)
+		return nil
+	}
+	if (record.Dynamic != nil || record.Geo != nil) && !slices.Contains(octoDNSDynamicTypes, recordType) {
+		c.addIssue(location, recordID, "geo and dynamic sections are supported only for A, AAAA and CNAME records")
+		record.Dynamic = nil
+		record.Geo = nil
+	}
+
+	var records []*DNSRecord
+	regions := map[string]bool{}
+	switch {
+	case record.Dynamic != nil:
+		for i, rule := range record.Dynamic.Rules {
+			if len(rule.Subnets) > 0 {
+				c.addIssue(location, recordID, "rule %d: subnets are not supported, the rule is skipped", i)
+				continue
+			}
+			chain, err := record.Dynamic.poolChain(rule.Pool)
+			if err != nil {
+				c.addIssue(location, recordID, "rule %d: %s", i, err)
+				continue
+			}
+			mode, value := c.importPoolChain(location, recordID, recordType, chain)
+			ruleRegions := []string{"default"}
+			if len(rule.Geos) > 0 {
+				ruleRegions = c.importGeos(location, recordID, rule.Geos)
+			}
+			for _, region := range ruleRegions {
+				if regions[region] {
+					c.addIssue(location, recordID, "rule %d: region %s is already served by a previous rule", i, region)
+					continue
+				}
+				regions[region] = true
+				records = append(records, newRecord(region, mode, value))
+			}
+		}
+	case record.Geo != nil:
+		geos := maps.Keys(record.Geo)
+		sort.Strings(geos)
+		regionValues := map[string][]interface{}{}
+		geosMap := c.importGeosMap(location, recordID, geos)
+		for _, region := range sortedKeys(geosMap) {
+			regionGeos := geosMap[region]
+			values := toInterfaceSlice(record.Geo[regionGeos[0]])
+			for _, geo := range regionGeos[1:] {
+				if !slices.Equal(record.Geo[geo], record.Geo[regionGeos[0]]) {
+					c.addIssue(location, recordID, "values of %s differ from %s, values of %s are used for region %s",
+						geo, regionGeos[0], regionGeos[0], region)
+				}
+			}
+			regionValues[region] = values
+		}
+		for _, region := range sortedKeys(regionValues) {
+			value, err := importOctoDNSValues(recordType, regionValues[region])
+			if err != nil {
+				c.addIssue(location, recordID, "invalid geo value: %s", err)
+				continue
+			}
+			regions[region] = true
+			records = append(records, newRecord(region, "standard", value))
+		}
+	}
+
+	if !regions["default"] {
+		if defaultValue == nil {
+			c.addIssue(location, recordID, "record has no values")
+			return nil
+		}
+		records = append([]*DNSRecord{newRecord("default", "standard", defaultValue)}, records...)
+	}
+
+	var valid []*DNSRecord
+	for _, item := range records {
+		err = validateDNSRecordValue(item)
+		if err != nil {
+			c.addIssue(location, recordID, "%s", err)
+			continue
+		}
+		valid = append(valid, item)
+	}
+	return valid
+}
+
+// importGeos returns GTD regions which serve the geo codes
+func (c *octoDNSConverter) importGeos(location string, recordID string, geos []string) []string {
+	return sortedKeys(c.importGeosMap(location, recordID, geos))
+}
+
+// importGeosMap groups geo codes by GTD regions which serve them. Codes which
+// cover only a part of the region are reported
+func (c *octoDNSConverter) importGeosMap(location string, recordID string, geos []string) map[string][]string {
+	regionGeos := map[string][]string{}
+	for _, geo := range geos {
+		region, ok := geoRegion(geo)
+		if !ok {
+			c.addIssue(location, recordID, "geo %s doesn't match any GTD region and is skipped", geo)
+			continue
+		}
+		regionGeos[region] = append(regionGeos[region], strings.ToUpper(geo))
+	}
+	for _, region := range sortedKeys(regionGeos) {
+		items := regionGeos[region]
+		expected := slices.Clone(gtdRegionGeos[region])
+		sort.Strings(expected)
+		actual := slices.Clone(items)
+		sort.Strings(actual)
+		if !slices.Equal(expected, slices.Compact(actual)) {
+			c.addIssue(location, recordID, "%s is served by the whole GTD region %s", strings.Join(items, ", "), region)
+		}
+	}
+	return regionGeos
+}
+
+// importPoolChain converts an octoDNS pool with its fallbacks to the record
+// mode and value. A chain of single value pools is converted to failover,
+// other chains with fallbacks to roundrobin-failover
+func (c *octoDNSConverter) importPoolChain(
+	location string, recordID string, recordType string, chain []*octoDNSPool,
+) (string, interface{}) {
+	weights := map[int]bool{}
+	for _, pool := range chain {
+		for _, item := range pool.Values {
+			if item.Weight > 0 {
+				weights[item.Weight] = true
+			}
+		}
+	}
+	if len(weights) > 1 {
+		c.addIssue(location, recordID, "weights of pool values are not supported, values are served equally")
+	}
+
+	if len(chain) == 1 {
+		value := make([]*DNSStandardItemValue, 0, len(chain[0].Values))
+		for _, item := range chain[0].Values {
+			value = append(value, &DNSStandardItemValue{Value: item.Value, Enabled: item.Status != "down"})
+		}
+		return "standard", value
+	}
+
+	singleValues := true
+	var values []*DNSFailoverItemValue
+	for i, pool := range chain {
+		singleValues = singleValues && len(pool.Values) == 1
+		for _, item := range pool.Values {
+			values = append(values, &DNSFailoverItemValue{Value: item.Value, Order: i + 1, Enabled: item.Status != "down"})
+		}
+	}
+	c.addIssue(location, recordID, "octoDNS healthchecks are not translated, set sonarCheckId of failover values")
+	if singleValues {
+		return "failover", &DNSFailoverValue{Mode: "normal", Enabled: true, Values: values}
+	}
+	if recordType == "CNAME" {
+		c.addIssue(location, recordID, "roundrobin-failover is not supported for CNAME records, only the first pool is used")
+		return c.importPoolChain(location, recordID, recordType, chain[:1])
+	}
+	return "roundrobin-failover", values
+}
+
+// sortedKeys returns keys of the map in ascending order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := maps.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
+
+func toInterfaceSlice(values []string) []interface{} {
+	items := make([]interface{}, len(values))
+	for i, value := range values {
+		items[i] = value
+	}
+	return items
+}
+
+// importOctoDNSValues converts values of an octoDNS record to the value of a
+// DNS record in standard mode
+func importOctoDNSValues(recordType string, values []interface{}) (interface{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	mapValue := func(v interface{}) (map[string]interface{}, error) {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a map, got %v", v)
+		}
+		return m, nil
+	}
+	str := func(v interface{}) string {
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(v)
+	}
+
+	switch recordType {
+	case "A", "AAAA", "ANAME", "CNAME", "NS", "PTR", "TXT", "SPF":
+		if (recordType == "CNAME" || recordType == "ANAME") && len(values) > 1 {
+			return nil, fmt.Errorf("only a single value is allowed")
+		}
+		value := make([]*DNSStandardItemValue, len(values))
+		for i, v := range values {
+			s := str(v)
+			if recordType == "TXT" || recordType == "SPF" {
+				// octoDNS requires semicolons to be escaped
+				s = strings.ReplaceAll(s, `\;`, ";")
+			}
+			value[i] = &DNSStandardItemValue{Value: s, Enabled: true}
+		}
+		return value, nil
+	case "MX":
+		value := make([]*DNSMXStandardItemValue, len(values))
+		for i, v := range values {
+			m, err := mapValue(v)
+			if err != nil {
+				return nil, err
+			}
+			// value and priority are deprecated names of exchange and preference
+			server, ok := m["exchange"]
+			if !ok {
+				server = m["value"]
+			}
+			priority, ok := m["preference"]
+			if !ok {
+				priority = m["priority"]
+			}
+			value[i] = &DNSMXStandardItemValue{Server: str(server), Priority: toInt(priority), Enabled: true}
+		}
+		return value, nil
+	case "SRV":
+		value := make([]*DNSSRVStandardItemValue, len(values))
+		for i, v := range values {
+			m, err := mapValue(v)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = &DNSSRVStandardItemValue{
+				Priority: toInt(m["priority"]),
+				Weight:   toInt(m["weight"]),
+				Port:     toInt(m["port"]),
+				Host:     str(m["target"]),
+				Enabled:  true,
+			}
+		}
+		return value, nil
+	case "CAA":
+		value := make([]*DNSCAAStandardItemValue, len(values))
+		for i, v := range values {
+			m, err := mapValue(v)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = &DNSCAAStandardItemValue{
+				Flag: toInt(m["flags"]), Tag: str(m["tag"]), Data: str(m["value"]), Enabled: true,
+			}
+		}
+		return value, nil
+	case "NAPTR":
+		value := make([]*DNSNAPTRStandardItemValue, len(values))
+		for i, v := range values {
+			m, err := mapValue(v)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = &DNSNAPTRStandardItemValue{
+				Order:             toInt(m["order"]),
+				Preference:        toInt(m["preference"]),
+				Flags:             str(m["flags"]),
+				Service:           str(m["service"]),
+				RegularExpression: str(m["regexp"]),
+				Replacement:       str(m["replacement"]),
+				Enabled:           true,
+			}
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("unsupported record type %s", recordType)
+}
+
+// exportOctoDNSZone converts DNS records to octoDNS zone configuration.
+// Records in GTD regions, failover, roundrobin-failover and pools modes are
+// converted to octoDNS dynamic pools and rules. Sonar checks, GeoProximities
+// and IP filters have no octoDNS equivalent and are reported
+func exportOctoDNSZone(records []*DNSRecord, resolver *activeValueResolver) (map[string]interface{}, []*importIssue, error) {
+	c := &octoDNSConverter{}
+	groups := map[string]map[string][]*DNSRecord{}
+	for _, record := range records {
+		location := record.Name
+		if location == "" {
+			location = "@"
+		}
+		switch {
+		case record.isApexNS():
+			// Managed by Constellix, like on import
+			continue
+		case !record.Enabled:
+			c.addIssue(location, record.GetResourceID(), "record is disabled and is not exported")
+			continue
+		case record.GeoProximity != nil:
+			c.addIssue(location, record.GetResourceID(), "GeoProximity is not supported by octoDNS, the record is skipped")
+			continue
+		case record.IPFilter != nil:
+			c.addIssue(location, record.GetResourceID(), "IP filter %v is not supported by octoDNS, the record is served to all clients", record.IPFilter)
+		}
+		if groups[record.Name] == nil {
+			groups[record.Name] = map[string][]*DNSRecord{}
+		}
+		groups[record.Name][record.Type] = append(groups[record.Name][record.Type], record)
+	}
+
+	zone := map[string]interface{}{}
+	for _, name := range sortedKeys(groups) {
+		types := groups[name]
+		var items []*octoDNSRecord
+		for _, recordType := range sortedKeys(types) {
+			item, err := c.exportRecords(types[recordType], resolver)
+			if err != nil {
+				return nil, nil, err
+			}
+			if item != nil {
+				items = append(items, item)
+			}
+		}
+		switch len(items) {
+		case 0:
+		case 1:
+			zone[name] = items[0]
+		default:
+			zone[name] = items
+		}
+	}
+	return zone, c.issues, nil
+}
+
+// exportRecords converts records with the same name and type to a single
+// octoDNS record
+func (c *octoDNSConverter) exportRecords(records []*DNSRecord, resolver *activeValueResolver) (*octoDNSRecord, error) {
+	first := records[0]
+	location := first.Name
+	if location == "" {
+		location = "@"
+	}
+	recordType := first.Type
+	switch recordType {
+	case "ANAME":
+		if first.Name != "" {
+			c.addIssue(location, first.GetResourceID(), "octoDNS supports ALIAS records only at the apex, the record is skipped")
+			return nil, nil
+		}
+		recordType = "ALIAS"
+	case "HTTP", "CERT", "HINFO", "RP":
+		c.addIssue(location, first.GetResourceID(), "record type %s is not supported by octoDNS", first.Type)
+		return nil, nil
+	}
+
+	// octoDNS has a single record for the name and type, so only one record
+	// of each region can be exported, e.g. records with different IP filters
+	var defaultRecord *DNSRecord
+	var regionRecords []*DNSRecord
+	regions := map[string]bool{}
+	for _, record := range records {
+		region := record.Region
+		if region == "" {
+			region = "default"
+		}
+		if regions[region] {
+			c.addIssue(location, record.GetResourceID(),
+				"another %s record is served in region %s, the record is skipped", record.Type, region)
+			continue
+		}
+		regions[region] = true
+		if region == "default" {
+			defaultRecord = record
+		} else {
+			regionRecords = append(regionRecords, record)
+		}
+	}
+	if len(regionRecords) > 0 && !slices.Contains(octoDNSDynamicTypes, first.Type) {
+		for _, record := range regionRecords {
+			c.addIssue(location, record.GetResourceID(), "GTD regions are supported only for A, AAAA and CNAME records")
+		}
+		regionRecords = nil
+	}
+	if defaultRecord == nil {
+		if len(regionRecords) == 0 {
+			return nil, nil
+		}
+		defaultRecord = regionRecords[0]
+		c.addIssue(location, first.GetResourceID(), "record has no default region, values of region %s are used by default",
+			defaultRecord.Region)
+	}
+	sort.SliceStable(regionRecords, func(i, j int) bool {
+		return regionRecords[i].Region < regionRecords[j].Region
+	})
+
+	item := &octoDNSRecord{Type: recordType, TTL: defaultRecord.TTL}
+	for _, record := range regionRecords {
+		if record.TTL != item.TTL {
+			c.addIssue(location, record.GetResourceID(), "TTL %d differs from TTL %d of the default region", record.TTL, item.TTL)
+		}
+	}
+
+	if defaultRecord.Mode == "standard" && len(regionRecords) == 0 {
+		values, err := exportOctoDNSValues(defaultRecord)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", defaultRecord.GetResourceID(), err)
+		}
+		if len(values) == 0 {
+			c.addIssue(location, defaultRecord.GetResourceID(), "record has no enabled values, the record is skipped")
+			return nil, nil
+		}
+		item.setValues(values)
+		return item, nil
+	}
+	if !slices.Contains(octoDNSDynamicTypes, first.Type) {
+		c.addIssue(location, defaultRecord.GetResourceID(), "%s mode is not supported by octoDNS for %s records, the record is skipped",
+			defaultRecord.Mode, first.Type)
+		return nil, nil
+	}
+
+	dynamic := &octoDNSDynamic{Pools: map[string]*octoDNSPool{}}
+	for _, record := range regionRecords {
+		geos, ok := gtdRegionGeos[record.Region]
+		if !ok {
+			c.addIssue(location, record.GetResourceID(), "GTD region %s has no octoDNS equivalent", record.Region)
+			continue
+		}
+		pool, values, err := c.exportPools(location, record, record.Region, dynamic, resolver)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) == 0 {
+			c.addIssue(location, record.GetResourceID(), "record has no enabled values, the region is skipped")
+			continue
+		}
+		dynamic.Rules = append(dynamic.Rules, &octoDNSRule{Geos: geos, Pool: pool})
+	}
+	pool, values, err := c.exportPools(location, defaultRecord, "default", dynamic, resolver)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		c.addIssue(location, defaultRecord.GetResourceID(), "record has no enabled values, the record is skipped")
+		return nil, nil
+	}
+	if defaultRecord.Mode != "standard" {
+		// Catch-all rule is the last one
+		dynamic.Rules = append(dynamic.Rules, &octoDNSRule{Pool: pool})
+	} else {
+		delete(dynamic.Pools, pool)
+	}
+	item.setValues(values)
+	if len(dynamic.Rules) > 0 {
+		item.Dynamic = dynamic
+	}
+	return item, nil
+}
+
+// exportPools adds octoDNS pools for the record to dynamic. Values with
+// different failover order are split into pools with fallbacks. Returns the
+// name of the first pool and all values. Nothing is added if all values of the
+// record are disabled
+func (c *octoDNSConverter) exportPools(
+	location string, record *DNSRecord, prefix string, dynamic *octoDNSDynamic, resolver *activeValueResolver,
+) (string, []interface{}, error) {
+	var groups [][]*octoDNSPoolValue
+	hasChecks := false
+	switch value := record.Value.(type) {
+	case []*DNSStandardItemValue:
+		var group []*octoDNSPoolValue
+		for _, item := range value {
+			if item.Enabled {
+				group = append(group, &octoDNSPoolValue{Value: item.Value})
+			}
+		}
+		groups = append(groups, group)
+	case *DNSFailoverValue, []*DNSFailoverItemValue:
+		items, ok := value.([]*DNSFailoverItemValue)
+		if !ok {
+			items = value.(*DNSFailoverValue).Values
+		}
+		sorted := slices.Clone(items)
+		slices.SortStableFunc(sorted, func(a, b *DNSFailoverItemValue) int {
+			return a.Order - b.Order
+		})
+		lastOrder := -1
+		for _, item := range sorted {
+			if !item.Enabled {
+				continue
+			}
+			hasChecks = hasChecks || item.SonarCheckID != 0
+			if item.Order != lastOrder {
+				groups = append(groups, nil)
+				lastOrder = item.Order
+			}
+			groups[len(groups)-1] = append(groups[len(groups)-1], &octoDNSPoolValue{Value: item.Value})
+		}
+	case []int:
+		var group []*octoDNSPoolValue
+		for _, id := range value {
+			pool, err := resolver.getPool(id)
+			if err != nil {
+				return "", nil, fmt.Errorf("%s: %w", record.GetResourceID(), err)
+			}
+			for _, item := range pool.Values {
+				if !item.Enabled {
+					continue
+				}
+				hasChecks = hasChecks || item.SonarCheckID != 0
+				poolValue := &octoDNSPoolValue{Value: item.Value}
+				if item.Weight > 0 && item.Weight <= 100 {
+					poolValue.Weight = item.Weight
+				}
+				group = append(group, poolValue)
+			}
+		}
+		groups = append(groups, group)
+	default:
+		return "", nil, fmt.Errorf("%s: unsupported value %T", record.GetResourceID(), record.Value)
+	}
+	if hasChecks {
+		c.addIssue(location, record.GetResourceID(), "Sonar checks are not translated, configure octoDNS healthchecks")
+	}
+
+	var values []interface{}
+	for _, group := range groups {
+		for _, item := range group {
+			values = append(values, formatOctoDNSValue(record.Type, item.Value))
+		}
+	}
+	if len(values) == 0 {
+		return "", nil, nil
+	}
+
+	names := make([]string, len(groups))
+	for i := range groups {
+		names[i] = prefix
+		if len(groups) > 1 {
+			names[i] = fmt.Sprintf("%s-%d", prefix, i+1)
+		}
+	}
+	for i, group := range groups {
+		pool := &octoDNSPool{Values: group}
+		if i+1 < len(groups) {
+			pool.Fallback = names[i+1]
+		}
+		dynamic.Pools[names[i]] = pool
+	}
+	return names[0], values, nil
+}
+
+func (r *octoDNSRecord) setValues(values []interface{}) {
+	if len(values) == 1 {
+		r.Value = values[0]
+	} else {
+		r.Values = values
+	}
+}
+
+func formatOctoDNSValue(recordType string, value string) string {
+	switch recordType {
+	case "TXT", "SPF":
+		// octoDNS requires semicolons to be escaped
+		return strings.ReplaceAll(strings.ReplaceAll(value, `\;`, ";"), ";", `\;`)
+	case "CNAME", "ANAME", "NS", "PTR":
+		return absoluteName(value)
+	}
+	return value
+}
+
+// exportOctoDNSValues converts enabled values of a record in standard mode to
+// octoDNS values. No values are returned if all of them are disabled
+func exportOctoDNSValues(record *DNSRecord) ([]interface{}, error) {
+	var values []interface{}
+	switch value := record.Value.(type) {
+	case []*DNSStandardItemValue:
+		for _, item := range value {
+			if item.Enabled {
+				values = append(values, formatOctoDNSValue(record.Type, item.Value))
+			}
+		}
+	case []*DNSMXStandardItemValue:
+		for _, item := range value {
+			if item.Enabled {
+				values = append(values, &octoDNSMXValue{Exchange: absoluteName(item.Server), Preference: item.Priority})
+			}
+		}
+	case []*DNSSRVStandardItemValue:
+		for _, item := range value {
+			if item.Enabled {
+				values = append(values, &octoDNSSRVValue{
+					Port: item.Port, Priority: item.Priority, Target: absoluteName(item.Host), Weight: item.Weight,
+				})
+			}
+		}
+	case []*DNSCAAStandardItemValue:
+		for _, item := range value {
+			if item.Enabled {
+				values = append(values, &octoDNSCAAValue{Flags: item.Flag, Tag: item.Tag, Value: item.Data})
+			}
+		}
+	case []*DNSNAPTRStandardItemValue:
+		for _, item := range value {
+			if item.Enabled {
+				values = append(values, &octoDNSNAPTRValue{
+					Flags:       item.Flags,
+					Order:       item.Order,
+					Preference:  item.Preference,
+					Regexp:      item.RegularExpression,
+					Replacement: item.Replacement,
+					Service:     item.Service,
+				})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported value %T", record.Value)
+	}
+	return values, nil
+}
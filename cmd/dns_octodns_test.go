@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+const testOctoDNSZone = `---
+'':
+  - type: A
+    values:
+      - 192.0.2.1
+      - 192.0.2.2
+  - type: MX
+    ttl: 600
+    values:
+      - exchange: mx1.example.com.
+        preference: 10
+  - type: TXT
+    value: v=spf1 include:_spf.example.net \; ~all
+  - type: NS
+    values: [ns1.example.net.]
+geo:
+  type: A
+  value: 192.0.2.10
+  geo:
+    EU: [192.0.2.11]
+    NA-US-CA: [192.0.2.12]
+    AF: [192.0.2.13]
+www:
+  type: A
+  ttl: 60
+  value: 192.0.2.20
+  dynamic:
+    pools:
+      primary:
+        fallback: secondary
+        values:
+          - value: 192.0.2.21
+      secondary:
+        values:
+          - value: 192.0.2.22
+      asia:
+        values:
+          - value: 192.0.2.23
+          - value: 192.0.2.24
+    rules:
+      - geos: [AS]
+        pool: asia
+      - subnets: [10.0.0.0/8]
+        pool: asia
+      - pool: primary
+_sip._tcp:
+  type: SRV
+  value:
+    port: 5060
+    priority: 10
+    target: sip.example.com.
+    weight: 20
+key:
+  type: SSHFP
+  value:
+    algorithm: 1
+    fingerprint: abc
+    fingerprint_type: 1
+`
+
+func Test_importOctoDNSZone(t *testing.T) {
+	records, issues, err := importOctoDNSZone([]byte(testOctoDNSZone))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedIDs := []string{
+		`A "" (default, 0)`,
+		`MX "" (default, 0)`,
+		`TXT "" (default, 0)`,
+		`A "geo" (default, 0)`,
+		`A "geo" (europe, 0)`,
+		`A "geo" (us-west, 0)`,
+		`A "www" (asia-pacific, 0)`,
+		`A "www" (default, 0)`,
+		`SRV "_sip._tcp" (default, 0)`,
+	}
+	if len(records) != len(expectedIDs) {
+		t.Fatalf("expected %d records, got %d", len(expectedIDs), len(records))
+	}
+	for i, record := range records {
+		if record.GetResourceID() != expectedIDs[i] {
+			t.Errorf("expected %s, got %s", expectedIDs[i], record.GetResourceID())
+		}
+	}
+	if records[0].TTL != octoDNSDefaultTTL || records[1].TTL != 600 {
+		t.Errorf("unexpected TTL %d, %d", records[0].TTL, records[1].TTL)
+	}
+	txt := records[2].Value.([]*DNSStandardItemValue)
+	if txt[0].Value != "v=spf1 include:_spf.example.net ; ~all" {
+		t.Errorf("unexpected TXT value %q", txt[0].Value)
+	}
+	failover, ok := records[7].Value.(*DNSFailoverValue)
+	if !ok || records[7].Mode != "failover" {
+		t.Fatalf("expected failover, got %s %T", records[7].Mode, records[7].Value)
+	}
+	if len(failover.Values) != 2 || failover.Values[1].Value != "192.0.2.22" || failover.Values[1].Order != 2 {
+		t.Errorf("unexpected failover values %+v", failover.Values)
+	}
+	asia := records[6].Value.([]*DNSStandardItemValue)
+	if len(asia) != 2 {
+		t.Errorf("expected 2 values, got %d", len(asia))
+	}
+
+	reasons := []string{}
+	for _, issue := range issues {
+		reasons = append(reasons, issue.Record+": "+issue.Reason)
+	}
+	for _, expected := range []string{
+		`NS "": apex NS records are managed by Constellix`,
+		`A "geo": geo AF doesn't match any GTD region and is skipped`,
+		`A "geo": NA-US-CA is served by the whole GTD region us-west`,
+		`A "www": rule 1: subnets are not supported, the rule is skipped`,
+		`A "www": octoDNS healthchecks are not translated, set sonarCheckId of failover values`,
+		`SSHFP "key": record type SSHFP is not supported by Constellix`,
+	} {
+		found := false
+		for _, reason := range reasons {
+			found = found || reason == expected
+		}
+		if !found {
+			t.Errorf("expected issue %q, got %q", expected, reasons)
+		}
+	}
+}
+
+func Test_exportOctoDNSZone_round_trip(t *testing.T) {
+	records, _, err := importOctoDNSZone([]byte(testOctoDNSZone))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zone, issues, err := exportOctoDNSZone(records, newActiveValueResolver())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("unexpected issues %+v", issues[0])
+	}
+	data, err := yaml.Marshal(zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`value: v=spf1 include:_spf.example.net \; ~all`,
+		"fallback: default-2",
+		"- NA-US-WY",
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("expected %q in:\n%s", expected, data)
+		}
+	}
+
+	exported, issues, err := importOctoDNSZone(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != len(records) {
+		t.Fatalf("expected %d records, got %d:\n%s", len(records), len(exported), data)
+	}
+	byID := map[string]*DNSRecord{}
+	for _, record := range exported {
+		byID[record.GetResourceID()] = record
+	}
+	for _, record := range records {
+		item, ok := byID[record.GetResourceID()]
+		if !ok {
+			t.Errorf("%s is not exported", record.GetResourceID())
+			continue
+		}
+		expected := &ExpectedDNSRecord{DNSRecord: *record}
+		expected.definedFieldsMap = getFieldNamesMap(&expected.DNSRecord, "yaml", "name", "type", "ttl", "mode", "value")
+		action, diffs, err := Compare(expected, item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if action != ActionOK {
+			t.Errorf("%s: expected no changes, got %+v", record.GetResourceID(), diffs)
+		}
+	}
+	// Only the failover record is reported
+	if len(issues) != 1 {
+		t.Errorf("expected 1 issue, got %d", len(issues))
+	}
+}
+
+func Test_exportOctoDNSZone_issues(t *testing.T) {
+	records := []*DNSRecord{
+		{Name: "go", Type: "HTTP", TTL: 60, Mode: "standard", Region: "default", Enabled: true,
+			Value: &DNSHTTPStandardItemValue{URL: "https://example.org"}},
+		{Name: "www", Type: "A", TTL: 60, Mode: "standard", Region: "default", Enabled: true, GeoProximity: 5,
+			Value: []*DNSStandardItemValue{{Value: "192.0.2.1", Enabled: true}}},
+		{Name: "off", Type: "A", TTL: 60, Mode: "standard", Region: "default", Enabled: false,
+			Value: []*DNSStandardItemValue{{Value: "192.0.2.1", Enabled: true}}},
+		{Name: "web", Type: "A", TTL: 60, Mode: "roundrobin-failover", Region: "default", Enabled: true,
+			Value: []*DNSFailoverItemValue{
+				{Value: "192.0.2.1", Order: 1, SonarCheckID: 1, Enabled: true},
+				{Value: "192.0.2.2", Order: 1, SonarCheckID: 2, Enabled: true},
+			}},
+	}
+	zone, issues, err := exportOctoDNSZone(records, newActiveValueResolver())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 4 {
+		t.Errorf("expected 4 issues, got %d", len(issues))
+	}
+	if len(zone) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(zone))
+	}
+	web := zone["web"].(*octoDNSRecord)
+	if web.Dynamic == nil || len(web.Dynamic.Pools["default"].Values) != 2 || web.Dynamic.Rules[0].Pool != "default" {
+		t.Errorf("unexpected dynamic section %+v", web.Dynamic)
+	}
+}
+
+func Test_exportOctoDNSZone_default_record_collision(t *testing.T) {
+	records := []*DNSRecord{
+		{Name: "www", Type: "A", TTL: 60, Mode: "standard", Region: "default", Enabled: true, IPFilter: 1,
+			Value: []*DNSStandardItemValue{{Value: "192.0.2.1", Enabled: true}}},
+		{Name: "www", Type: "A", TTL: 60, Mode: "standard", Region: "default", Enabled: true, IPFilter: 2,
+			Value: []*DNSStandardItemValue{{Value: "192.0.2.2", Enabled: true}}},
+	}
+	zone, issues, err := exportOctoDNSZone(records, newActiveValueResolver())
+	if err != nil {
+		t.Fatal(err)
+	}
+	www, ok := zone["www"].(*octoDNSRecord)
+	if !ok || www.Value != "192.0.2.1" {
+		t.Errorf("want values of the first record, got %+v", zone["www"])
+	}
+	reported := false
+	for _, issue := range issues {
+		if strings.Contains(issue.Reason, "another A record is served in region default") {
+			reported = true
+		}
+	}
+	if !reported {
+		t.Errorf("want the skipped record to be reported, got %+v", issues)
+	}
+}
+
+func Test_exportOctoDNSZone_no_enabled_values(t *testing.T) {
+	records := []*DNSRecord{
+		{Name: "off", Type: "A", TTL: 60, Mode: "standard", Region: "default", Enabled: true,
+			Value: []*DNSStandardItemValue{{Value: "192.0.2.1", Enabled: false}}},
+		{Name: "web", Type: "A", TTL: 60, Mode: "failover", Region: "default", Enabled: true,
+			Value: []*DNSFailoverItemValue{{Value: "192.0.2.1", Order: 1, Enabled: false}}},
+		{Name: "www", Type: "A", TTL: 60, Mode: "standard", Region: "default", Enabled: true,
+			Value: []*DNSStandardItemValue{{Value: "192.0.2.1", Enabled: true}}},
+	}
+	zone, issues, err := exportOctoDNSZone(records, newActiveValueResolver())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zone) != 1 || zone["www"] == nil {
+		t.Errorf("want only www to be exported, got %+v", zone)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("want 2 issues, got %+v", issues)
+	}
+	for _, issue := range issues {
+		if !strings.Contains(issue.Reason, "no enabled values") {
+			t.Errorf("unexpected issue %+v", issue)
+		}
+	}
+}
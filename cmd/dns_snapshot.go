@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// dnsSnapshotDir is the directory where DNS records are saved before changes
+// are applied. Unless dnsNoRollback is set, the records are restored from the
+// snapshots when any of the changes fails
+var dnsSnapshotDir = "snapshots"
+var dnsNoRollback bool
+
+// Fields of DNS record which are restored from a snapshot
+var dnsRecordSnapshotFields = []string{
+	"name", "type", "ttl", "mode", "region", "ipfilter", "ipfilterDrop",
+	"geoFailover", "geoproximity", "enabled", "value", "notes",
+}
+
+// DNSSnapshot contains DNS records of a domain before changes are applied
+type DNSSnapshot struct {
+	Domain    string       `json:"domain"`
+	DomainID  int          `json:"domainId"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Records   []*DNSRecord `json:"records"`
+}
+
+// UnmarshalJSON parses the snapshot. Records in the snapshot reference IP
+// filters and GeoProximities by ID, unlike responses of Constellix API
+func (s *DNSSnapshot) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Domain    string           `json:"domain"`
+		DomainID  int              `json:"domainId"`
+		CreatedAt time.Time        `json:"createdAt"`
+		Records   []aliasDNSRecord `json:"records"`
+	}
+	err := json.Unmarshal(b, &raw)
+	if err != nil {
+		return err
+	}
+	s.Domain = raw.Domain
+	s.DomainID = raw.DomainID
+	s.CreatedAt = raw.CreatedAt
+	s.Records = make([]*DNSRecord, 0, len(raw.Records))
+	for _, item := range raw.Records {
+		record := DNSRecord(item)
+		err = populateDNSRecordValue(&record)
+		if err != nil {
+			return fmt.Errorf("%s: %w", record.GetResourceID(), err)
+		}
+		if record.IPFilter != nil {
+			record.IPFilter = toInt(record.IPFilter)
+		}
+		if record.GeoProximity != nil {
+			record.GeoProximity = toInt(record.GeoProximity)
+		}
+		record.domainIDInConstellix = s.DomainID
+		s.Records = append(s.Records, &record)
+	}
+	return nil
+}
+
+// writeDNSSnapshot saves active DNS records of the domain to a timestamped
+// file in the directory. Returns the snapshot and the path to the file
+func writeDNSSnapshot(dir string, domain string, domainID int, records []*DNSRecord) (*DNSSnapshot, string, error) {
+	snapshot := &DNSSnapshot{
+		Domain:    domain,
+		DomainID:  domainID,
+		CreatedAt: time.Now().UTC(),
+		Records:   records,
+	}
+	dataBytes, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, "", err
+	}
+	snapshotFile := filepath.Join(
+		dir, fmt.Sprintf("dns-%s-%s.json", domain, snapshot.CreatedAt.Format("20060102T150405.000Z")),
+	)
+	err = os.WriteFile(snapshotFile, dataBytes, 0644)
+	if err != nil {
+		return nil, "", err
+	}
+	logger.Printf("DNS records for %s saved to %s\n", domain, snapshotFile)
+	return snapshot, snapshotFile, nil
+}
+
+// readDNSSnapshot reads a snapshot saved by writeDNSSnapshot
+func readDNSSnapshot(snapshotFile string) (*DNSSnapshot, error) {
+	dataBytes, err := os.ReadFile(snapshotFile)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot DNSSnapshot
+	err = json.Unmarshal(dataBytes, &snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read DNS snapshot %s: %w", snapshotFile, err)
+	}
+	return &snapshot, nil
+}
+
+// newExpectedDNSRecordFromSnapshot returns the expected state of the record
+// from the snapshot
+func newExpectedDNSRecordFromSnapshot(record *DNSRecord) *ExpectedDNSRecord {
+	ex := &ExpectedDNSRecord{
+		immutableFields: []string{"type"},
+		DNSRecord:       *record,
+	}
+	ex.definedFieldsMap = getFieldNamesMap(&ex.DNSRecord, "yaml", dnsRecordSnapshotFields...)
+	return ex
+}
+
+// planDNSRollback plans changes which restore DNS records of the domain from
+// the snapshot. Records are matched by their ID in Constellix: records which
+// are not in the snapshot are removed, removed ones are created again and
+// changed ones are reverted to the snapshot values
+func planDNSRollback(snapshot *DNSSnapshot) (*SyncPlan, error) {
+	records, err := GetDNSRecords(snapshot.DomainID)
+	if err != nil {
+		return nil, err
+	}
+	plan := &SyncPlan{Title: "Rollback of DNS records for " + snapshot.Domain}
+
+	snapshotIDs := map[int]bool{}
+	for _, record := range snapshot.Records {
+		snapshotIDs[record.ID] = true
+	}
+	activeRecords := map[int]*DNSRecord{}
+	for _, record := range records {
+		activeRecords[record.ID] = record
+		if !snapshotIDs[record.ID] {
+			plan.Entries = append(plan.Entries, &PlanEntry{
+				Action:       ActionDelete,
+				ResourceID:   record.GetResourceID(),
+				ConstellixID: record.ID,
+				active:       record,
+			})
+		}
+	}
+
+	for _, record := range snapshot.Records {
+		expected := newExpectedDNSRecordFromSnapshot(record)
+		active, ok := activeRecords[record.ID]
+		if !ok {
+			plan.Entries = append(plan.Entries, &PlanEntry{
+				Action:     ActionCreate,
+				ResourceID: expected.GetResourceID(),
+				expected:   expected,
+			})
+			continue
+		}
+		action, diffs, err := Compare(expected, active)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", expected.GetResourceID(), err)
+		}
+		plan.Entries = append(plan.Entries, &PlanEntry{
+			Action:       action,
+			ResourceID:   expected.GetResourceID(),
+			ConstellixID: active.ID,
+			Diffs:        diffs,
+			expected:     expected,
+			active:       active,
+		})
+	}
+	return plan, nil
+}
+
+// rollbackDNSRecords restores DNS records of the domain from the snapshot
+func rollbackDNSRecords(snapshot *DNSSnapshot) error {
+	plan, err := planDNSRollback(snapshot)
+	if err != nil {
+		return err
+	}
+	if summarizePlans(plan) == (PlanSummary{}) {
+		logger.Printf("DNS records for %s match the snapshot, nothing to roll back\n", snapshot.Domain)
+		return nil
+	}
+	reportPlans(plan.Title, false, plan)
+	logSyncSummary(plan)
+	logger.Println("Rolling back changes...")
+	return syncChanges(plan.toDelete(), plan.toUpdate(), plan.toCreate())
+}
+
+// applyWithDNSRollback saves DNS records of domains with planned changes to
+// snapshots and calls apply. The records the plans were made with are saved,
+// they are not retrieved again. If any of the attempted changes fails, also
+// with --keep-going, the records are restored from the snapshots. Nothing is
+// rolled back when apply fails before any change is attempted, e.g. when
+// deletion is not allowed
+func applyWithDNSRollback(plans []*SyncPlan, apply func() error) error {
+	snapshots := []*DNSSnapshot{}
+	snapshotFiles := []string{}
+	for _, plan := range plans {
+		if plan.dnsDomain == nil || summarizePlans(plan) == (PlanSummary{}) {
+			continue
+		}
+		snapshot, snapshotFile, err := writeDNSSnapshot(
+			dnsSnapshotDir, plan.dnsDomain.Name, plan.dnsDomain.ID, plan.dnsRecords,
+		)
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, snapshot)
+		snapshotFiles = append(snapshotFiles, snapshotFile)
+	}
+
+	failed := countFailedSyncChanges()
+	err := apply()
+	if countFailedSyncChanges() == failed {
+		return err
+	}
+	// With --keep-going, failed changes are only reported in the results
+	resultsErr := reportSyncResults()
+	if err == nil {
+		err = resultsErr
+	}
+	if dnsNoRollback || len(snapshots) == 0 {
+		return err
+	}
+
+	logger.Printf("Sync failed: %s\n", err)
+	var rollbackErrs []string
+	for i, snapshot := range snapshots {
+		rollbackErr := rollbackFailedDNSChanges(snapshot)
+		if rollbackErr != nil {
+			rollbackErrs = append(rollbackErrs, fmt.Sprintf(
+				"rollback of %s failed: %s, restore records with 'mech dns rollback %s'",
+				snapshot.Domain, rollbackErr, snapshotFiles[i],
+			))
+		}
+	}
+	if len(rollbackErrs) > 0 {
+		return fmt.Errorf("%w; %s", err, strings.Join(rollbackErrs, "; "))
+	}
+	return fmt.Errorf("%w; changes have been rolled back", err)
+}
+
+// rollbackFailedDNSChanges restores DNS records from the snapshot after a
+// failed sync. Results of the rollback are not collected with --keep-going,
+// so its failure is always returned
+func rollbackFailedDNSChanges(snapshot *DNSSnapshot) error {
+	keepGoing := syncKeepGoing
+	syncKeepGoing = false
+	defer func() {
+		syncKeepGoing = keepGoing
+	}()
+	return rollbackDNSRecords(snapshot)
+}
+
+// addRollbackFlags adds flags which control snapshots of DNS records saved
+// before changes are applied
+func addRollbackFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(
+		&dnsSnapshotDir, "snapshot-dir", "snapshots", "directory where DNS records are saved before changes are applied",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&dnsNoRollback, "no-rollback", false, "don't restore DNS records from the snapshots when any of the changes fails",
+	)
+}
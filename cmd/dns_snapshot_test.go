@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+const dnsSnapshotTestRecords = `{"data":[` +
+	`{"id":1,"name":"www","type":"A","ttl":300,"mode":"standard","region":"default","enabled":true,` +
+	`"geoproximity":{"id":5,"name":"amsterdam"},"ipfilter":{"id":7,"name":"office"},` +
+	`"value":[{"value":"1.1.1.1","enabled":true}]},` +
+	`{"id":2,"name":"","type":"MX","ttl":300,"mode":"standard","region":"default","enabled":true,` +
+	`"value":[{"server":"mx.example.com.","priority":10,"enabled":true}]}` +
+	`],"meta":{"pagination":{"total":2,"count":2,"perPage":100,"currentPage":1,"totalPages":1}}}`
+
+func Test_DNSSnapshot_round_trip(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(dnsSnapshotTestRecords))
+	}))
+	defer ts.Close()
+
+	originalDNSRESTAPIBaseURL := dnsRESTAPIBaseURL
+	defer func() {
+		dnsRESTAPIBaseURL = originalDNSRESTAPIBaseURL
+	}()
+	dnsRESTAPIBaseURL = ts.URL
+
+	records, err := GetDNSRecords(10)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, snapshotFile, err := writeDNSSnapshot(t.TempDir(), "example.com", 10, records)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	snapshot, err := readDNSSnapshot(snapshotFile)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if snapshot.Domain != "example.com" || snapshot.DomainID != 10 {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+		return
+	}
+	if !reflect.DeepEqual(snapshot.Records, records) {
+		t.Errorf("want %+v, got %+v", records, snapshot.Records)
+	}
+}
+
+func Test_planDNSRollback(t *testing.T) {
+	// Record 1 was updated, record 2 was removed and record 3 was created
+	current := `{"data":[` +
+		`{"id":1,"name":"www","type":"A","ttl":300,"mode":"standard","region":"default","enabled":true,` +
+		`"geoproximity":{"id":5,"name":"amsterdam"},"ipfilter":{"id":7,"name":"office"},` +
+		`"value":[{"value":"2.2.2.2","enabled":true}]},` +
+		`{"id":3,"name":"new","type":"A","ttl":300,"mode":"standard","region":"default","enabled":true,` +
+		`"value":[{"value":"3.3.3.3","enabled":true}]}` +
+		`],"meta":{"pagination":{"total":2,"count":2,"perPage":100,"currentPage":1,"totalPages":1}}}`
+	response := dnsSnapshotTestRecords
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(response))
+	}))
+	defer ts.Close()
+
+	originalDNSRESTAPIBaseURL := dnsRESTAPIBaseURL
+	defer func() {
+		dnsRESTAPIBaseURL = originalDNSRESTAPIBaseURL
+	}()
+	dnsRESTAPIBaseURL = ts.URL
+
+	records, err := GetDNSRecords(10)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	snapshot := &DNSSnapshot{Domain: "example.com", DomainID: 10, Records: records}
+
+	response = current
+	plan, err := planDNSRollback(snapshot)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	type step struct {
+		action       ResourceAction
+		constellixID int
+	}
+	expected := []step{{ActionDelete, 3}, {ActionUpate, 1}, {ActionCreate, 0}}
+	if len(plan.Entries) != len(expected) {
+		t.Errorf("want %d entries, got %d", len(expected), len(plan.Entries))
+		return
+	}
+	for idx, entry := range plan.Entries {
+		if entry.Action != expected[idx].action || entry.ConstellixID != expected[idx].constellixID {
+			t.Errorf("entry %d: want %v, got %s %d", idx, expected[idx], entry.Action, entry.ConstellixID)
+		}
+	}
+	if len(plan.Entries[1].Diffs) != 1 || plan.Entries[1].Diffs[0].FieldName != "Value" {
+		t.Errorf("want diff in Value, got %+v", plan.Entries[1].Diffs)
+	}
+	if plan.Entries[2].ResourceID != `MX "" (default, 0)` {
+		t.Errorf("unexpected resource to create: %s", plan.Entries[2].ResourceID)
+	}
+}
+
+// newDNSRollbackTestAPI returns the fake API with records www (ID 1) and old
+// (ID 2) of example.com and the configuration which updates www and creates
+// api. Record old is kept withOld, otherwise it is removed
+func newDNSRollbackTestAPI(t *testing.T, withOld bool) (*fakeConstellixAPI, string) {
+	api := newFakeConstellixAPI(t)
+	api.add("/domains", `[{"id":10,"name":"example.com"}]`)
+	api.add("/domains/10/records", `[
+{"id":1,"name":"www","type":"A","ttl":60,"mode":"standard","region":"default","enabled":true,
+ "value":[{"value":"192.0.2.1","enabled":true}]},
+{"id":2,"name":"old","type":"A","ttl":60,"mode":"standard","region":"default","enabled":true,
+ "value":[{"value":"192.0.2.9","enabled":true}]}]`)
+	record := func(name, value string) string {
+		return fmt.Sprintf(`- name: %s
+  type: A
+  ttl: 60
+  mode: standard
+  region: default
+  enabled: true
+  value:
+    - value: %s
+      enabled: true
+`, name, value)
+	}
+	records := record("www", "192.0.2.2") + record("api", "192.0.2.3")
+	if withOld {
+		records += record("old", "192.0.2.9")
+	}
+	configFile := writeTestConfig(t, map[string]string{
+		"config.yaml": `constellix:
+  dns:
+    example.com: [records.yaml]
+`,
+		"records.yaml": records,
+	})
+	return api, configFile
+}
+
+// resetApplyFlags restores flags of the sync commands changed by the test
+func resetApplyFlags() {
+	syncKeepGoing = false
+	for _, cmd := range []*cobra.Command{syncCmd, dnsSyncCmd} {
+		cmd.PersistentFlags().Set("doit", "false")
+		cmd.PersistentFlags().Set("remove", "false")
+	}
+}
+
+func Test_syncCmd_rolls_back_keep_going_failures(t *testing.T) {
+	api, configFile := newDNSRollbackTestAPI(t, true)
+	api.failures["POST /domains/10/records"] = http.StatusBadRequest
+	defer resetApplyFlags()
+
+	// Update of www succeeds and creation of api fails
+	_, err := executeCommand(rootCmd, "sync", "--config", configFile, "--doit", "--no-backup", "--keep-going")
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 changes failed; changes have been rolled back") {
+		t.Fatalf("want failed changes to be rolled back, got %v", err)
+	}
+	for _, record := range api.get("/domains/10/records") {
+		value := record["value"].([]interface{})[0].(map[string]interface{})
+		if record["name"] == "www" && value["value"] != "192.0.2.1" {
+			t.Errorf("want www to be rolled back, got %+v", record)
+		}
+	}
+	snapshots, err := os.ReadDir(dnsSnapshotDir)
+	if err != nil || len(snapshots) != 1 {
+		t.Errorf("want 1 snapshot, got %d: %v", len(snapshots), err)
+	}
+}
+
+func Test_dnsSyncCmd_snapshots_only_changed_domains(t *testing.T) {
+	api, _ := newDNSRollbackTestAPI(t, true)
+	api.add("/domains", `[{"id":20,"name":"example.org"}]`)
+	api.add("/domains/20/records", `[
+{"id":3,"name":"www","type":"A","ttl":60,"mode":"standard","region":"default","enabled":true,
+ "value":[{"value":"192.0.2.1","enabled":true}]}]`)
+	configFile := writeTestConfig(t, map[string]string{
+		"config.yaml": `constellix:
+  dns:
+    example.com: [com.yaml]
+    example.org: [org.yaml]
+`,
+		"com.yaml": `- name: www
+  type: A
+  ttl: 60
+  mode: standard
+  region: default
+  enabled: true
+  value:
+    - value: 192.0.2.2
+      enabled: true
+`,
+		"org.yaml": `- name: www
+  type: A
+  ttl: 60
+  mode: standard
+  region: default
+  enabled: true
+  value:
+    - value: 192.0.2.1
+      enabled: true
+`,
+	})
+	defer resetApplyFlags()
+
+	_, err := executeCommand(rootCmd, "dns", "sync", "--config", configFile, "--doit", "--no-backup", "--remove")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err := os.ReadDir(dnsSnapshotDir)
+	if err != nil || len(snapshots) != 1 || !strings.HasPrefix(snapshots[0].Name(), "dns-example.com-") {
+		t.Fatalf("want 1 snapshot of example.com, got %v: %v", snapshots, err)
+	}
+	snapshot, err := readDNSSnapshot(filepath.Join(dnsSnapshotDir, snapshots[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Records) != 2 {
+		t.Errorf("want records before the changes in the snapshot, got %d records", len(snapshot.Records))
+	}
+}
+
+func Test_dnsSyncCmd_reports_rollback_failure(t *testing.T) {
+	api, configFile := newDNSRollbackTestAPI(t, false)
+	api.failures["POST /domains/10/records"] = http.StatusBadRequest
+	defer resetApplyFlags()
+
+	// Removed record old can't be created again by the rollback
+	_, err := executeCommand(
+		rootCmd, "dns", "sync", "--config", configFile, "--doit", "--no-backup", "--remove", "--keep-going",
+	)
+	if err == nil || !strings.Contains(err.Error(), "rollback of example.com failed") ||
+		!strings.Contains(err.Error(), "mech dns rollback "+dnsSnapshotDir) {
+		t.Fatalf("want rollback failure, got %v", err)
+	}
+}
+
+func Test_dnsSyncCmd_no_rollback_when_deletion_is_not_allowed(t *testing.T) {
+	_, configFile := newDNSRollbackTestAPI(t, false)
+	defer resetApplyFlags()
+
+	_, err := executeCommand(rootCmd, "dns", "sync", "--config", configFile, "--doit", "--no-backup")
+	if err == nil || !strings.Contains(err.Error(), "deletion is not allowed") {
+		t.Fatalf("want deletion to be refused, got %v", err)
+	}
+	if strings.Contains(err.Error(), "rolled back") || strings.Contains(err.Error(), "rollback") {
+		t.Errorf("want no rollback, got %v", err)
+	}
+}
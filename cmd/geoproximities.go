@@ -46,7 +46,7 @@ func (ac *GeoProximity) SyncResourceDelete(constellixID int) error {
 			details += string(item)
 		}
 		logger.Println("  unexpected response. Details: " + details)
-		return fmt.Errorf("unable to delete GeoProximity: %s", err)
+		return fmt.Errorf("unable to delete GeoProximity: %w", err)
 	}
 	return nil
 }
@@ -144,7 +144,7 @@ func (ex *ExpectedGeoProximity) SyncResourceUpdate(constellixID int) error {
 			details += string(item)
 		}
 		logger.Println("  unexpected response. Details: " + details)
-		return fmt.Errorf("unable to update GeoProximity: %s", err)
+		return fmt.Errorf("unable to update GeoProximity: %w", err)
 	}
 	return nil
 }
@@ -167,7 +167,7 @@ func (ex *ExpectedGeoProximity) SyncResourceCreate() error {
 			details += string(item)
 		}
 		logger.Println("  unexpected response. Details: " + details)
-		return fmt.Errorf("unable to create GeoProximity: %s", err)
+		return fmt.Errorf("unable to create GeoProximity: %w", err)
 	}
 	return nil
 }
@@ -184,7 +184,7 @@ var GetGeoProximities = func() ([]*GeoProximity, error) {
 	}
 	data, err := makev4APIRequest("GET", endpoint, nil, 200)
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve GeoProximities: %s", err)
+		return nil, fmt.Errorf("unable to retrieve GeoProximities: %w", err)
 	}
 
 	geops := make([]*GeoProximity, 0)
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// hclExpr is an HCL expression written as is, e.g. a reference to another
+// resource
+type hclExpr string
+
+type hclAttribute struct {
+	name  string
+	value interface{}
+}
+
+// hclBlock is a block of Terraform configuration. Attributes and nested
+// blocks are written in the order they are added
+type hclBlock struct {
+	blockType  string
+	labels     []string
+	attributes []*hclAttribute
+	blocks     []*hclBlock
+}
+
+func newHCLBlock(blockType string, labels ...string) *hclBlock {
+	return &hclBlock{blockType: blockType, labels: labels}
+}
+
+// set adds the attribute
+func (b *hclBlock) set(name string, value interface{}) *hclBlock {
+	b.attributes = append(b.attributes, &hclAttribute{name: name, value: value})
+	return b
+}
+
+// setNonZero adds the attribute unless it has zero value
+func (b *hclBlock) setNonZero(name string, value interface{}) *hclBlock {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return b
+		}
+	case int:
+		if v == 0 {
+			return b
+		}
+	case float64:
+		if v == 0 {
+			return b
+		}
+	case bool:
+		if !v {
+			return b
+		}
+	case []int:
+		if len(v) == 0 {
+			return b
+		}
+	}
+	return b.set(name, value)
+}
+
+// block adds a nested block and returns it
+func (b *hclBlock) block(blockType string) *hclBlock {
+	nested := newHCLBlock(blockType)
+	b.blocks = append(b.blocks, nested)
+	return nested
+}
+
+func (b *hclBlock) write(sb *strings.Builder, indent string) {
+	sb.WriteString(indent + b.blockType)
+	for _, label := range b.labels {
+		sb.WriteString(" " + quoteHCLString(label))
+	}
+	sb.WriteString(" {\n")
+	width := 0
+	for _, attr := range b.attributes {
+		width = max(width, len(attr.name))
+	}
+	for _, attr := range b.attributes {
+		fmt.Fprintf(sb, "%s  %-*s = %s\n", indent, width, attr.name, formatHCLValue(attr.value))
+	}
+	for i, nested := range b.blocks {
+		if i > 0 || len(b.attributes) > 0 {
+			sb.WriteString("\n")
+		}
+		nested.write(sb, indent+"  ")
+	}
+	sb.WriteString(indent + "}\n")
+}
+
+// renderHCL renders blocks separated with empty lines
+func renderHCL(blocks []*hclBlock) string {
+	var sb strings.Builder
+	for i, block := range blocks {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		block.write(&sb, "")
+	}
+	return sb.String()
+}
+
+func formatHCLValue(value interface{}) string {
+	switch v := value.(type) {
+	case hclExpr:
+		return string(v)
+	case string:
+		return quoteHCLString(v)
+	case []int:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatHCLValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// quoteHCLString quotes the string, escaping template sequences
+func quoteHCLString(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", "$${", "%{", "%%{",
+	)
+	return `"` + replacer.Replace(s) + `"`
+}
+
+var invalidHCLIdentifierChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// hclNames generates unique Terraform resource names
+type hclNames map[string]bool
+
+// name returns a valid and unique resource name for the resource type
+func (n hclNames) name(resourceType string, parts ...string) string {
+	name := strings.ToLower(strings.Join(parts, "_"))
+	name = strings.Trim(invalidHCLIdentifierChars.ReplaceAllString(name, "_"), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "r_" + name
+	}
+	unique := name
+	for i := 2; n[resourceType+"."+unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	n[resourceType+"."+unique] = true
+	return unique
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// importIssue describes a record which can't be imported or converted
+// exactly
+type importIssue struct {
+	// Location of the record in the source, e.g. line of a zone file
+	Location string
+	Record   string
+	Reason   string
+}
+
+// dnsRecordConfig contains fields of a DNS record which are written to the
+// generated configuration. It is read back as ExpectedDNSRecord
+type dnsRecordConfig struct {
+	Name    string      `yaml:"name"`
+	Type    string      `yaml:"type"`
+	TTL     int         `yaml:"ttl"`
+	Mode    string      `yaml:"mode"`
+	Region  string      `yaml:"region"`
+	Enabled bool        `yaml:"enabled"`
+	Value   interface{} `yaml:"value"`
+	Notes   string      `yaml:"notes,omitempty"`
+}
+
+func newDNSRecordConfig(record *DNSRecord) *dnsRecordConfig {
+	return &dnsRecordConfig{
+		Name:    record.Name,
+		Type:    record.Type,
+		TTL:     record.TTL,
+		Mode:    record.Mode,
+		Region:  record.Region,
+		Enabled: record.Enabled,
+		Value:   record.Value,
+		Notes:   record.Notes,
+	}
+}
+
+// writeImportedDNSRecords writes DNS records in configuration format to the
+// file or prints them
+func writeImportedDNSRecords(records []*DNSRecord, outputFile string) error {
+	configs := make([]*dnsRecordConfig, len(records))
+	for i, record := range records {
+		configs[i] = newDNSRecordConfig(record)
+	}
+	return writeDiscoveryResult(configs, outputFile)
+}
+
+// reportImportIssues prints records which can't be imported
+func reportImportIssues(title string, issues []*importIssue) {
+	if len(issues) == 0 {
+		return
+	}
+	report := table.NewWriter()
+	if reportToTestBuffer {
+		// Skip header in tests
+		report.SetOutputMirror(testBuffer)
+	} else {
+		report.SetOutputMirror(logger.Writer())
+		report.SetTitle(title)
+		report.AppendHeader(table.Row{"Location", "Record", "Reason"})
+	}
+	for _, issue := range issues {
+		report.AppendRow(table.Row{issue.Location, issue.Record, Yellow + issue.Reason + Reset})
+	}
+	printReport(report)
+}
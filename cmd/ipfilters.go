@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// IPFilter represents a list of rules (continents, countries, regions, ASNs and
+// networks) which can be attached to DNS records
+type IPFilter struct {
+	ID         int               `json:"id"`
+	Name       string            `json:"name" yaml:"name"`
+	RulesLimit int               `json:"rulesLimit" yaml:"rulesLimit"`
+	Continents []string          `json:"continents" yaml:"continents"`
+	Countries  []string          `json:"countries" yaml:"countries"`
+	Regions    []*IPFilterRegion `json:"regions" yaml:"regions"`
+	ASN        []int             `json:"asn" yaml:"asn"`
+	IPv4       []string          `json:"ipv4" yaml:"ipv4"`
+	IPv6       []string          `json:"ipv6" yaml:"ipv6"`
+}
+
+type IPFilterRegion struct {
+	Continent string `json:"continent" yaml:"continent"`
+	Country   string `json:"country" yaml:"country"`
+	Region    string `json:"region" yaml:"region"`
+}
+
+func (ac *IPFilter) GetResource() interface{} {
+	return ac
+}
+
+func (ac *IPFilter) GetResourceID() string {
+	return ac.Name
+}
+
+func (ac *IPFilter) GetConstellixID() int {
+	return ac.ID
+}
+
+func (ac *IPFilter) SyncResourceDelete(constellixID int) error {
+	logger.Printf("  removing resource %q\n", ac.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "ipfilters", fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	data, err := makev4APIRequest("DELETE", endpoint, nil, 204)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to delete IP filter: %w", err)
+	}
+	return nil
+}
+
+type ExpectedIPFilter struct {
+	// Mapping of defined fields from parsed data to struct Field Names
+	definedFieldsMap map[string]string
+	// List of immutable fields which can't be updated via API
+	immutableFields []string
+	// List of mandatory fields which must be defined, used for validation
+	mandatoryFields []string
+	IPFilter
+}
+
+// UnmarshalYAML unmarshals the mesage and stores original fields
+func (ex *ExpectedIPFilter) UnmarshalYAML(value *yaml.Node) error {
+	ex.immutableFields = []string{}
+	ex.mandatoryFields = []string{"name"}
+
+	// Unmarshall data into IPFilter struct
+	var s IPFilter
+	err := value.Decode(&s)
+	if err != nil {
+		return err
+	}
+	ex.IPFilter = s
+
+	// Save specified fields
+	dm := make(map[string]interface{})
+	err = value.Decode(&dm)
+	if err != nil {
+		return err
+	}
+
+	definedFields := make([]string, len(dm))
+	i := 0
+	for k := range dm {
+		definedFields[i] = k
+		i++
+	}
+	ex.definedFieldsMap = getFieldNamesMap(&ex.IPFilter, "yaml", definedFields...)
+	return nil
+}
+
+// Validate performs simple validation of user provided data
+func (ex *ExpectedIPFilter) Validate() error {
+	// Validate that all mandatory fields are present
+	for _, f := range ex.mandatoryFields {
+		if !slices.Contains(maps.Keys(ex.definedFieldsMap), f) {
+			return fmt.Errorf("%s: mandatory field %q is not defined", ex.Name, f)
+		}
+	}
+	// Validate that networks are either IP addresses or CIDRs
+	for _, network := range append(append([]string{}, ex.IPv4...), ex.IPv6...) {
+		if net.ParseIP(network) == nil {
+			if _, _, err := net.ParseCIDR(network); err != nil {
+				return fmt.Errorf("%s: invalid network %q", ex.Name, network)
+			}
+		}
+	}
+	return nil
+}
+
+// GetDefinedStructFieldNames returns list of defined struct fields from local configuration
+func (ex *ExpectedIPFilter) GetDefinedStructFieldNames() []string {
+	return maps.Values(ex.definedFieldsMap)
+}
+
+// GetImmutableStructFields returns list of immutable struct fields
+func (ex *ExpectedIPFilter) GetImmutableStructFields() []string {
+	var imf []string
+	for k, v := range ex.definedFieldsMap {
+		if slices.Contains(ex.immutableFields, k) {
+			imf = append(imf, v)
+		}
+	}
+	return imf
+}
+
+func (ex *ExpectedIPFilter) GetResource() interface{} {
+	return ex.IPFilter
+}
+
+func (ex *ExpectedIPFilter) GetResourceID() string {
+	return ex.Name
+}
+
+func (ex *ExpectedIPFilter) SyncResourceUpdate(constellixID int) error {
+	logger.Printf("  updating resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "ipfilters", fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), nil)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	data, err := makev4APIRequest("PUT", endpoint, payloadReader, 200)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to update IP filter: %w", err)
+	}
+	return nil
+}
+
+func (ex *ExpectedIPFilter) SyncResourceCreate() error {
+	logger.Printf("  creating new resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "ipfilters")
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), nil)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	data, err := makev4APIRequest("POST", endpoint, payloadReader, 201)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to create IP filter: %w", err)
+	}
+	return nil
+}
+
+// GetIPFilters returns active IP filters
+// We also cache this response to avoid making API calls when parsing configuration
+// files (@ipfilter:... syntax)
+var GetIPFilters = func() ([]*IPFilter, error) {
+	if logLevel > 0 {
+		logger.Println("Retrieving IP filters...")
+	}
+	if cachedIPFilters != nil {
+		if logLevel > 0 {
+			logger.Println("  using cached IP filters")
+		}
+		return cachedIPFilters, nil
+	}
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "ipfilters")
+	if err != nil {
+		return nil, err
+	}
+	data, err := makev4APIRequest("GET", endpoint, nil, 200)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve IP filters: %w", err)
+	}
+
+	filters := make([]*IPFilter, 0)
+	for _, item := range data {
+		var tmpFilters []*IPFilter
+		err = json.Unmarshal(item, &tmpFilters)
+		if err != nil {
+			return nil, err
+		}
+		if len(tmpFilters) > 0 {
+			filters = append(filters, tmpFilters...)
+		}
+	}
+
+	cachedIPFilters = filters
+	return filters, nil
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writePlanFile saves plans to the file, which can be applied later with the
+// apply command. Every entry contains the checksum of the expected resource to
+// detect changes in the configuration file and the checksum of the active
+// resource to detect changes made in Constellix
+func writePlanFile(planFile, configFile string, remove bool, plans []*SyncPlan) error {
+	absConfigFile, err := filepath.Abs(configFile)
+	if err != nil {
+		return err
+	}
+	document := PlanDocument{Config: absConfigFile, Remove: remove, Summary: summarizePlans(plans...)}
+	for _, plan := range plans {
+		saved := &SyncPlan{Title: plan.Title}
+		for _, entry := range plan.Entries {
+			savedEntry := *entry
+			savedEntry.Checksum, err = getPlanEntryChecksum(entry)
+			if err != nil {
+				return err
+			}
+			savedEntry.ActiveChecksum, err = getPlanEntryActiveChecksum(entry)
+			if err != nil {
+				return err
+			}
+			saved.Entries = append(saved.Entries, &savedEntry)
+		}
+		document.Plans = append(document.Plans, saved)
+	}
+	dataBytes, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(planFile, dataBytes, 0644)
+	if err != nil {
+		return err
+	}
+	logger.Printf("Planned changes saved to %s\n", planFile)
+	return nil
+}
+
+// readPlanFile reads plans saved by writePlanFile
+func readPlanFile(planFile string) (*PlanDocument, error) {
+	dataBytes, err := os.ReadFile(planFile)
+	if err != nil {
+		return nil, err
+	}
+	var document PlanDocument
+	err = json.Unmarshal(dataBytes, &document)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read plan file %s: %s", planFile, err)
+	}
+	return &document, nil
+}
+
+// getPlanEntryChecksum returns the checksum of the expected resource of the
+// entry. Deleted resources have no expected resource and no checksum
+func getPlanEntryChecksum(entry *PlanEntry) (string, error) {
+	if entry.expected == nil {
+		return "", nil
+	}
+	return getResourceChecksum(entry.expected.GetResource())
+}
+
+// getPlanEntryActiveChecksum returns the checksum of the active resource of
+// the entry, so updated or deleted resources are not changed by the plan, if
+// they were edited after the plan was made. Created resources have no checksum
+func getPlanEntryActiveChecksum(entry *PlanEntry) (string, error) {
+	if entry.active == nil {
+		return "", nil
+	}
+	return getResourceChecksum(entry.active.GetResource())
+}
+
+func getResourceChecksum(resource interface{}) (string, error) {
+	dataBytes, err := json.Marshal(resource)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(dataBytes)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// verifyPlans checks that freshly planned changes are exactly the same as the
+// saved ones: the live state of every planned resource still has the recorded
+// old values and the configuration of the resources hasn't changed
+func verifyPlans(saved []*SyncPlan, fresh []*SyncPlan) error {
+	mismatches := []string{}
+	freshPlans := map[string]*SyncPlan{}
+	for _, plan := range fresh {
+		freshPlans[plan.Title] = plan
+	}
+	savedPlans := map[string]*SyncPlan{}
+	for _, plan := range saved {
+		savedPlans[plan.Title] = plan
+	}
+
+	for _, savedPlan := range saved {
+		freshPlan, ok := freshPlans[savedPlan.Title]
+		if !ok {
+			freshPlan = &SyncPlan{Title: savedPlan.Title}
+		}
+		freshEntries := map[string]*PlanEntry{}
+		for _, entry := range freshPlan.Entries {
+			freshEntries[entry.ResourceID] = entry
+		}
+		for _, savedEntry := range savedPlan.Entries {
+			freshEntry, ok := freshEntries[savedEntry.ResourceID]
+			if !ok {
+				mismatches = append(mismatches, fmt.Sprintf(
+					"%s: %q is in the plan, but not found anymore", savedPlan.Title, savedEntry.ResourceID,
+				))
+				continue
+			}
+			delete(freshEntries, savedEntry.ResourceID)
+			entryMismatches, err := compareEntries(savedEntry, freshEntry)
+			if err != nil {
+				return err
+			}
+			for _, mismatch := range entryMismatches {
+				mismatches = append(mismatches, fmt.Sprintf("%s: %q %s", savedPlan.Title, savedEntry.ResourceID, mismatch))
+			}
+		}
+		// Anything else must be in sync
+		for _, entry := range freshPlan.Entries {
+			if _, ok := freshEntries[entry.ResourceID]; ok && entry.Action != ActionOK {
+				mismatches = append(mismatches, fmt.Sprintf(
+					"%s: %q is not in the plan, but now it is planned to %s", savedPlan.Title, entry.ResourceID, entry.Action,
+				))
+			}
+		}
+	}
+
+	for _, freshPlan := range fresh {
+		if _, ok := savedPlans[freshPlan.Title]; ok {
+			continue
+		}
+		for _, entry := range freshPlan.Entries {
+			if entry.Action != ActionOK {
+				mismatches = append(mismatches, fmt.Sprintf(
+					"%s: %q is not in the plan, but now it is planned to %s", freshPlan.Title, entry.ResourceID, entry.Action,
+				))
+			}
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf(
+			"live state or configuration no longer matches the plan:\n  %s", strings.Join(mismatches, "\n  "),
+		)
+	}
+	return nil
+}
+
+// compareEntries returns the list of differences between saved and freshly
+// planned entries of the same resource
+func compareEntries(saved, fresh *PlanEntry) ([]string, error) {
+	mismatches := []string{}
+	if saved.Action != fresh.Action {
+		mismatches = append(mismatches, fmt.Sprintf("is planned to %s, but now it is %s", saved.Action, fresh.Action))
+		return mismatches, nil
+	}
+	if saved.ConstellixID != fresh.ConstellixID {
+		mismatches = append(mismatches, fmt.Sprintf(
+			"had Constellix ID %d when planned, but now it is %d", saved.ConstellixID, fresh.ConstellixID,
+		))
+	}
+	for _, savedDiff := range saved.Diffs {
+		freshDiff := getFieldDiff(fresh.Diffs, savedDiff.FieldName)
+		if freshDiff == nil || freshDiff.OldValue != savedDiff.OldValue {
+			mismatches = append(mismatches, fmt.Sprintf(
+				"field %s was %q when planned, but it has changed", savedDiff.FieldName, savedDiff.OldValue,
+			))
+		}
+	}
+	for _, freshDiff := range fresh.Diffs {
+		if getFieldDiff(saved.Diffs, freshDiff.FieldName) == nil {
+			mismatches = append(mismatches, fmt.Sprintf(
+				"field %s has changed since the plan was made", freshDiff.FieldName,
+			))
+		}
+	}
+	checksum, err := getPlanEntryChecksum(fresh)
+	if err != nil {
+		return nil, err
+	}
+	if saved.Checksum != checksum {
+		mismatches = append(mismatches, "configuration has changed since the plan was made")
+	}
+	activeChecksum, err := getPlanEntryActiveChecksum(fresh)
+	if err != nil {
+		return nil, err
+	}
+	if saved.ActiveChecksum != activeChecksum {
+		mismatches = append(mismatches, "has been changed in Constellix since the plan was made")
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func planForTest(port, activePort int) []*SyncPlan {
+	er := &testExpectedResource{
+		Name:          "Field1",
+		Port:          port,
+		definedFields: []string{"Port"},
+	}
+	ar := &testActiveResource{
+		Name:         "Field1",
+		Port:         activePort,
+		constellixID: 999,
+	}
+	plan, _ := planSync(
+		toResourceMatcher([]*testExpectedResource{er}),
+		toResourceMatcher([]*testActiveResource{ar}),
+		"Test resources",
+	)
+	return []*SyncPlan{plan}
+}
+
+func savedPlanForTest(t *testing.T, plans []*SyncPlan) *PlanDocument {
+	planFile := filepath.Join(t.TempDir(), "plan.json")
+	err := writePlanFile(planFile, "config.yaml", true, plans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err := readPlanFile(planFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return document
+}
+
+func Test_readPlanFile(t *testing.T) {
+	document := savedPlanForTest(t, planForTest(80, 443))
+	if !filepath.IsAbs(document.Config) || !document.Remove {
+		t.Errorf("unexpected document: %+v", document)
+		return
+	}
+	if document.Summary.Update != 1 || len(document.Plans) != 1 || len(document.Plans[0].Entries) != 1 {
+		t.Errorf("unexpected document: %+v", document)
+		return
+	}
+	entry := document.Plans[0].Entries[0]
+	if entry.Action != ActionUpate || entry.ConstellixID != 999 || entry.Checksum == "" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+}
+
+func Test_verifyPlans(t *testing.T) {
+	document := savedPlanForTest(t, planForTest(80, 443))
+	tests := []struct {
+		name  string
+		fresh []*SyncPlan
+		want  string
+	}{
+		{"unchanged", planForTest(80, 443), ""},
+		{"live state changed", planForTest(80, 8080), `field Port was "443" when planned, but it has changed`},
+		{"configuration changed", planForTest(8000, 443), "configuration has changed since the plan was made"},
+		{"already in sync", planForTest(80, 80), "is planned to update, but now it is ok"},
+		{"not planned", append(planForTest(80, 443), &SyncPlan{
+			Title:   "Other resources",
+			Entries: []*PlanEntry{{Action: ActionCreate, ResourceID: "Field2"}},
+		}), `"Field2" is not in the plan, but now it is planned to create`},
+		{"removed", []*SyncPlan{}, `"Field1" is in the plan, but not found anymore`},
+	}
+	for _, tt := range tests {
+		err := verifyPlans(document.Plans, tt.fresh)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: want error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func deletePlanForTest(activePort int) []*SyncPlan {
+	ar := &testActiveResource{
+		Name:         "Field1",
+		Port:         activePort,
+		constellixID: 999,
+	}
+	plan, _ := planSync(
+		toResourceMatcher([]*testExpectedResource{}),
+		toResourceMatcher([]*testActiveResource{ar}),
+		"Test resources",
+	)
+	return []*SyncPlan{plan}
+}
+
+func Test_verifyPlans_deleted_resource_changed(t *testing.T) {
+	document := savedPlanForTest(t, deletePlanForTest(443))
+	entry := document.Plans[0].Entries[0]
+	if entry.Action != ActionDelete || entry.ActiveChecksum == "" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+
+	err := verifyPlans(document.Plans, deletePlanForTest(443))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	// The record was edited after the plan was reviewed, it must not be deleted
+	err = verifyPlans(document.Plans, deletePlanForTest(8080))
+	want := `"Field1" has been changed in Constellix since the plan was made`
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("want error containing %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var poolResourceIDTemplate = "%s %q"
+var supportedPoolTypes = []string{"A", "AAAA", "CNAME"}
+
+// Pool represents a record pool which can be used by A, AAAA and CNAME records
+// in pools mode
+type Pool struct {
+	ID              int          `json:"id"`
+	Name            string       `json:"name" yaml:"name"`
+	Type            string       `json:"type" yaml:"type"`
+	Return          int          `json:"return" yaml:"return"`
+	MinimumFailover int          `json:"minimumFailover" yaml:"minimumFailover"`
+	Enabled         bool         `json:"enabled" yaml:"enabled"`
+	Values          []*PoolValue `json:"values" yaml:"values"`
+}
+
+type PoolValue struct {
+	Value        string `json:"value" yaml:"value"`
+	Weight       int    `json:"weight" yaml:"weight"`
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+	Handicap     int    `json:"handicap" yaml:"handicap"`
+	Policy       string `json:"policy" yaml:"policy"`
+	SonarCheckID int    `json:"sonarCheckId" yaml:"sonarCheckId"`
+}
+
+// UnmarshalYAML resolves sonarCheckId which can be specified as
+// @sonar,<check_type>:<check_name>
+func (v *PoolValue) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		Value        string      `yaml:"value"`
+		Weight       int         `yaml:"weight"`
+		Enabled      bool        `yaml:"enabled"`
+		Handicap     int         `yaml:"handicap"`
+		Policy       string      `yaml:"policy"`
+		SonarCheckID interface{} `yaml:"sonarCheckId"`
+	}
+	err := value.Decode(&raw)
+	if err != nil {
+		return err
+	}
+	sonarCheckID, sonarCheckHost, err := getSonarCheckID(raw.SonarCheckID)
+	if err != nil {
+		return err
+	}
+	if raw.Value == "" {
+		raw.Value = sonarCheckHost
+	}
+	*v = PoolValue{
+		Value:        raw.Value,
+		Weight:       raw.Weight,
+		Enabled:      raw.Enabled,
+		Handicap:     raw.Handicap,
+		Policy:       raw.Policy,
+		SonarCheckID: sonarCheckID,
+	}
+	return nil
+}
+
+func (ac *Pool) GetResource() interface{} {
+	return ac
+}
+
+func (ac *Pool) GetResourceID() string {
+	return fmt.Sprintf(poolResourceIDTemplate, ac.Type, ac.Name)
+}
+
+func (ac *Pool) GetConstellixID() int {
+	return ac.ID
+}
+
+func (ac *Pool) SyncResourceDelete(constellixID int) error {
+	logger.Printf("  removing resource %q\n", ac.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "pools", ac.Type, fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	data, err := makev4APIRequest("DELETE", endpoint, nil, 204)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to delete Pool: %w", err)
+	}
+	return nil
+}
+
+type ExpectedPool struct {
+	// Mapping of defined fields from parsed data to struct Field Names
+	definedFieldsMap map[string]string
+	// List of immutable fields which can't be updated via API
+	immutableFields []string
+	// List of mandatory fields which must be defined, used for validation
+	mandatoryFields []string
+	// References to Sonar checks which don't exist in Constellix yet
+	pendingReferences []string
+	Pool
+}
+
+// UnmarshalYAML unmarshals the mesage and stores original fields
+func (ex *ExpectedPool) UnmarshalYAML(value *yaml.Node) error {
+	ex.immutableFields = []string{"type"}
+	ex.mandatoryFields = []string{"name", "type", "return", "minimumFailover", "values"}
+
+	// Unmarshall data into Pool struct
+	takePendingReferences()
+	var s Pool
+	err := value.Decode(&s)
+	if err != nil {
+		return err
+	}
+	ex.Pool = s
+	ex.pendingReferences = takePendingReferences()
+
+	// Save specified fields
+	dm := make(map[string]interface{})
+	err = value.Decode(&dm)
+	if err != nil {
+		return err
+	}
+
+	definedFields := make([]string, len(dm))
+	i := 0
+	for k := range dm {
+		definedFields[i] = k
+		i++
+	}
+	ex.definedFieldsMap = getFieldNamesMap(&ex.Pool, "yaml", definedFields...)
+	return nil
+}
+
+// Validate performs simple validation of user provided data
+func (ex *ExpectedPool) Validate() error {
+	// Validate that all mandatory fields are present
+	for _, f := range ex.mandatoryFields {
+		if !slices.Contains(maps.Keys(ex.definedFieldsMap), f) {
+			return fmt.Errorf("%s: mandatory field %q is not defined", ex.Name, f)
+		}
+	}
+	if !slices.Contains(supportedPoolTypes, ex.Type) {
+		return fmt.Errorf("%s: unsupported pool type %q, want one of %q", ex.Name, ex.Type, supportedPoolTypes)
+	}
+	return nil
+}
+
+// GetDefinedStructFieldNames returns list of defined struct fields from local configuration
+func (ex *ExpectedPool) GetDefinedStructFieldNames() []string {
+	return maps.Values(ex.definedFieldsMap)
+}
+
+// GetImmutableStructFields returns list of immutable struct fields
+func (ex *ExpectedPool) GetImmutableStructFields() []string {
+	var imf []string
+	for k, v := range ex.definedFieldsMap {
+		if slices.Contains(ex.immutableFields, k) {
+			imf = append(imf, v)
+		}
+	}
+	return imf
+}
+
+// GetPendingReferences returns references to Sonar checks which don't exist
+// in Constellix yet
+func (ex *ExpectedPool) GetPendingReferences() []string {
+	return ex.pendingReferences
+}
+
+func (ex *ExpectedPool) GetResource() interface{} {
+	return ex.Pool
+}
+
+func (ex *ExpectedPool) GetResourceID() string {
+	return fmt.Sprintf(poolResourceIDTemplate, ex.Type, ex.Name)
+}
+
+func (ex *ExpectedPool) SyncResourceUpdate(constellixID int) error {
+	logger.Printf("  updating resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "pools", ex.Type, fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), ex.immutableFields)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	data, err := makev4APIRequest("PUT", endpoint, payloadReader, 200)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to update Pool: %w", err)
+	}
+	return nil
+}
+
+func (ex *ExpectedPool) SyncResourceCreate() error {
+	logger.Printf("  creating new resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "pools")
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), nil)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	data, err := makev4APIRequest("POST", endpoint, payloadReader, 201)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to create Pool: %w", err)
+	}
+	return nil
+}
+
+// GetPools returns active pools
+// We also cache this response to avoid making API calls when parsing configuration
+// files (@pool:... syntax)
+var GetPools = func() ([]*Pool, error) {
+	if logLevel > 0 {
+		logger.Println("Retrieving Pools...")
+	}
+	if cachedPools != nil {
+		if logLevel > 0 {
+			logger.Println("  using cached Pools")
+		}
+		return cachedPools, nil
+	}
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "pools")
+	if err != nil {
+		return nil, err
+	}
+	data, err := makev4APIRequest("GET", endpoint, nil, 200)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve Pools: %w", err)
+	}
+
+	pools := make([]*Pool, 0)
+	for _, item := range data {
+		var tmpPools []*Pool
+		err = json.Unmarshal(item, &tmpPools)
+		if err != nil {
+			return nil, err
+		}
+		if len(tmpPools) > 0 {
+			pools = append(pools, tmpPools...)
+		}
+	}
+
+	cachedPools = pools
+	return pools, nil
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Example:
+//
+//	{
+//	  "id": 83647,
+//	  "name": "dns-test",
+//	  "fqdn": "example.com",
+//	  "resolver": "ns11.constellix.com",
+//	  "resolverIPVersion": "IPV4",
+//	  "recordType": "A",
+//	  "expectedResponse": "159.69.18.28",
+//	  "compareOptions": "ANYMATCH",
+//	  "acceptCNAME": true,
+//	  "note": "",
+//	  "userId": 300003895,
+//	  "interval": "ONEMINUTE",
+//	  "monitorIntervalPolicy": "PARALLEL",
+//	  "checkSites": [
+//	    4
+//	  ],
+//	  "notificationGroups": [],
+//	  "scheduleId": 0,
+//	  "notificationReportTimeout": 1440,
+//	  "verificationPolicy": "SIMPLE"
+//	}
+type SonarDNSCheck struct {
+	ID                        int    `json:"id"`
+	Name                      string `json:"name" yaml:"name"`
+	FQDN                      string `json:"fqdn" yaml:"fqdn"`
+	Resolver                  string `json:"resolver" yaml:"resolver"`
+	ResolverIPVersion         string `json:"resolverIPVersion" yaml:"resolverIPVersion"`
+	RecordType                string `json:"recordType" yaml:"recordType"`
+	ExpectedResponse          string `json:"expectedResponse" yaml:"expectedResponse"`
+	CompareOptions            string `json:"compareOptions" yaml:"compareOptions"`
+	AcceptCNAME               bool   `json:"acceptCNAME" yaml:"acceptCNAME"`
+	Interval                  string `json:"interval" yaml:"interval"`
+	CheckSites                []int  `json:"checkSites" yaml:"checkSites"`
+	Note                      string `json:"note" yaml:"note"`
+	UserID                    int    `json:"userId" yaml:"userId"`
+	MonitorIntervalPolicy     string `json:"monitorIntervalPolicy" yaml:"monitorIntervalPolicy"`
+	NotificationGroups        []int  `json:"notificationGroups" yaml:"notificationGroups"`
+	ScheduleID                int    `json:"scheduleId" yaml:"scheduleId"`
+	NotificationReportTimeout int    `json:"notificationReportTimeout" yaml:"notificationReportTimeout"`
+	VerificationPolicy        string `json:"verificationPolicy" yaml:"verificationPolicy"`
+}
+
+func (ac *SonarDNSCheck) GetResource() interface{} {
+	return ac
+}
+
+func (ac *SonarDNSCheck) GetResourceID() string {
+	return ac.Name
+}
+
+func (ac *SonarDNSCheck) GetConstellixID() int {
+	return ac.ID
+}
+
+func (ac *SonarDNSCheck) SyncResourceDelete(constellixID int) error {
+	logger.Printf("  removing resource %q\n", ac.GetResourceID())
+	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "dns", fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	body, err := makeSimpleAPIRequest("DELETE", endpoint, nil, 202)
+	if err != nil {
+		logger.Println("  unexpected response. Details: " + string(body))
+		return fmt.Errorf("unable to delete Sonar DNS checks: %w", err)
+	}
+	return nil
+}
+
+type ExpectedSonarDNSCheck struct {
+	// Mapping of defined fields from parsed data to struct Field Names
+	definedFieldsMap map[string]string
+	// List of immutable fields which can't be updated via API
+	immutableFields []string
+	// List of mandatory fields which must be defined, used for validation
+	mandatoryFields []string
+	SonarDNSCheck
+}
+
+// UnmarshalYAML unmarshals the mesage and stores original fields
+func (ex *ExpectedSonarDNSCheck) UnmarshalYAML(value *yaml.Node) error {
+	ex.immutableFields = []string{"fqdn", "resolverIPVersion"}
+	ex.mandatoryFields = []string{"name", "fqdn", "resolver", "resolverIPVersion", "recordType", "interval", "checkSites"}
+
+	// Unmarshall data into SonarDNSCheck struct
+	var s SonarDNSCheck
+	err := value.Decode(&s)
+	if err != nil {
+		return err
+	}
+	ex.SonarDNSCheck = s
+
+	// Save specified fields
+	dm := make(map[string]interface{})
+	err = value.Decode(&dm)
+	if err != nil {
+		return err
+	}
+
+	definedFields := make([]string, len(dm))
+	i := 0
+	for k := range dm {
+		definedFields[i] = k
+		i++
+	}
+	ex.definedFieldsMap = getFieldNamesMap(&ex.SonarDNSCheck, "yaml", definedFields...)
+	return nil
+}
+
+// Validate performs simple validation of user provided data
+func (ex *ExpectedSonarDNSCheck) Validate() error {
+	// Validate that all mandatory fields are present
+	for _, f := range ex.mandatoryFields {
+		if !slices.Contains(maps.Keys(ex.definedFieldsMap), f) {
+			return fmt.Errorf("%s: mandatory field %q is not defined", ex.Name, f)
+		}
+	}
+	return nil
+}
+
+// GetDefinedStructFieldNames returns list of defined struct fields from local configuration
+func (ex *ExpectedSonarDNSCheck) GetDefinedStructFieldNames() []string {
+	return maps.Values(ex.definedFieldsMap)
+}
+
+// GetImmutableStructFields returns list of immutable struct fields
+func (ex *ExpectedSonarDNSCheck) GetImmutableStructFields() []string {
+	var imf []string
+	for k, v := range ex.definedFieldsMap {
+		if slices.Contains(ex.immutableFields, k) {
+			imf = append(imf, v)
+		}
+	}
+	return imf
+}
+
+func (ex *ExpectedSonarDNSCheck) GetResource() interface{} {
+	return ex.SonarDNSCheck
+}
+
+func (ex *ExpectedSonarDNSCheck) GetResourceID() string {
+	return ex.Name
+}
+
+func (ex *ExpectedSonarDNSCheck) SyncResourceUpdate(constellixID int) error {
+	logger.Printf("  updating resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "dns", fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), ex.immutableFields)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	body, err := makeSimpleAPIRequest("PUT", endpoint, payloadReader, 200)
+	if err != nil {
+		logger.Println("  unexpected response. Details: " + string(body))
+		return fmt.Errorf("unable to update Sonar DNS checks: %w", err)
+	}
+	return nil
+}
+
+func (ex *ExpectedSonarDNSCheck) SyncResourceCreate() error {
+	logger.Printf("  creating new resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "dns")
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), nil)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	body, err := makeSimpleAPIRequest("POST", endpoint, payloadReader, 201)
+	if err != nil {
+		logger.Println("  unexpected response. Details: " + string(body))
+		return fmt.Errorf("unable to create Sonar DNS checks: %w", err)
+	}
+	return nil
+}
+
+// GetSonarDNSChecks returns active Sonar Checks
+func GetSonarDNSChecks() ([]*SonarDNSCheck, error) {
+	// Fetch DNS checks
+	if logLevel > 0 {
+		logger.Println("Retrieving Sonar DNS Checks...")
+	}
+	if cachedSonarDNSChecks != nil {
+		if logLevel > 0 {
+			logger.Println("  using cached Sonar DNS Checks")
+		}
+		return cachedSonarDNSChecks, nil
+	}
+	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "dns")
+	if err != nil {
+		return nil, err
+	}
+	data, err := makeSimpleAPIRequest("GET", endpoint, nil, 200)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve Sonar DNS checks: %w", err)
+	}
+
+	checks := make([]*SonarDNSCheck, 0)
+	err = json.Unmarshal(data, &checks)
+	if err != nil {
+		return nil, err
+	}
+
+	cachedSonarDNSChecks = checks
+	return checks, nil
+}
+
+// GetSonarDNSCheckStatus returns active Sonar Check status using runtime endpoint
+func GetSonarDNSCheckStatus(id int) (ResourceRuntimeStatus, error) {
+	return getSonarCheckStatus("dns", id)
+}
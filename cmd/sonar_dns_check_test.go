@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestExpectedSonarDNSCheck_Validate_no_mandatory(t *testing.T) {
+	data := `
+name: dns-prod
+fqdn: example.com
+`
+	var obj ExpectedSonarDNSCheck
+	err := yaml.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = obj.Validate()
+	expected := "dns-prod: mandatory field \"resolver\" is not defined"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
+
+func TestExpectedSonarDNSCheck_SyncResourceUpdate_exclude_immutable(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		expected := `{"expectedResponse":"1.1.1.1","name":"dns-prod"}`
+		if r.URL.Path != "/dns/999" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if string(body) != expected {
+			t.Errorf("expected %q, got %q", expected, string(body))
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	originalSonarRESTAPIBaseURL := sonarRESTAPIBaseURL
+	defer func() {
+		sonarRESTAPIBaseURL = originalSonarRESTAPIBaseURL
+	}()
+	sonarRESTAPIBaseURL = ts.URL
+	data := `
+name: dns-prod
+fqdn: example.com
+expectedResponse: 1.1.1.1
+`
+	var obj ExpectedSonarDNSCheck
+	err := yaml.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = obj.SyncResourceUpdate(999)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+}
+
+func TestGetSonarCheckID_dns(t *testing.T) {
+	originalCache := cachedSonarDNSChecks
+	defer func() { cachedSonarDNSChecks = originalCache }()
+	cachedSonarDNSChecks = []*SonarDNSCheck{{ID: 14, Name: "resolve-prod", FQDN: "example.com"}}
+
+	// DNS checks have no host, so values of failover records are kept
+	id, host, err := getSonarCheckID("@sonar,dns:resolve-prod")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if id != 14 || host != "" {
+		t.Errorf("want 14 and no host, got %d and %q", id, host)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
-	"strconv"
 
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
@@ -95,7 +94,7 @@ func (ac *SonarHTTPCheck) SyncResourceDelete(constellixID int) error {
 	body, err := makeSimpleAPIRequest("DELETE", endpoint, nil, 202)
 	if err != nil {
 		logger.Println("  unexpected response. Details: " + string(body))
-		return fmt.Errorf("unable to delete Sonar HTTP checks: %s", err)
+		return fmt.Errorf("unable to delete Sonar HTTP checks: %w", err)
 	}
 	return nil
 }
@@ -189,7 +188,7 @@ func (ex *ExpectedSonarHTTPCheck) SyncResourceUpdate(constellixID int) error {
 	body, err := makeSimpleAPIRequest("PUT", endpoint, payloadReader, 200)
 	if err != nil {
 		logger.Println("  unexpected response. Details: " + string(body))
-		return fmt.Errorf("unable to update Sonar HTTP checks: %s", err)
+		return fmt.Errorf("unable to update Sonar HTTP checks: %w", err)
 	}
 	return nil
 }
@@ -208,7 +207,7 @@ func (ex *ExpectedSonarHTTPCheck) SyncResourceCreate() error {
 	body, err := makeSimpleAPIRequest("POST", endpoint, payloadReader, 201)
 	if err != nil {
 		logger.Println("  unexpected response. Details: " + string(body))
-		return fmt.Errorf("unable to create Sonar HTTP checks: %s", err)
+		return fmt.Errorf("unable to create Sonar HTTP checks: %w", err)
 	}
 	return nil
 }
@@ -233,7 +232,7 @@ func GetSonarHTTPChecks() ([]*SonarHTTPCheck, error) {
 	}
 	data, err := makeSimpleAPIRequest("GET", endpoint, nil, 200)
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve Sonar HTTP checks: %s", err)
+		return nil, fmt.Errorf("unable to retrieve Sonar HTTP checks: %w", err)
 	}
 
 	checks := make([]*SonarHTTPCheck, 0)
@@ -248,22 +247,5 @@ func GetSonarHTTPChecks() ([]*SonarHTTPCheck, error) {
 
 // GetSonarHTTPCheckStatus returns active Sonar Check status using runtime endpoint
 func GetSonarHTTPCheckStatus(id int) (ResourceRuntimeStatus, error) {
-	// Fetch HTTP checks
-	if logLevel > 0 {
-		logger.Printf("Retrieving status for Sonar HTTP Check %d...\n", id)
-	}
-	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "http", strconv.Itoa(id), "status")
-	if err != nil {
-		return "", err
-	}
-	data, err := makeSimpleAPIRequest("GET", endpoint, nil, 200)
-	if err != nil {
-		return "", fmt.Errorf("unable to retrieve Sonar HTTP check status: %s", err)
-	}
-	status := RuntimeStatus{Status: "unknown"}
-	err = json.Unmarshal(data, &status)
-	if err != nil {
-		return "", err
-	}
-	return status.Status, nil
+	return getSonarCheckStatus("http", id)
 }
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Example:
+//
+//	{
+//	  "id": 83646,
+//	  "name": "icmp-test",
+//	  "host": "159.69.18.28",
+//	  "ipVersion": "IPV4",
+//	  "pingCount": 4,
+//	  "packetSize": 56,
+//	  "note": "",
+//	  "runTraceroute": "DISABLED",
+//	  "userId": 300003895,
+//	  "interval": "ONEMINUTE",
+//	  "monitorIntervalPolicy": "PARALLEL",
+//	  "checkSites": [
+//	    4
+//	  ],
+//	  "notificationGroups": [],
+//	  "scheduleId": 0,
+//	  "notificationReportTimeout": 1440,
+//	  "verificationPolicy": "SIMPLE"
+//	}
+type SonarICMPCheck struct {
+	ID                        int    `json:"id"`
+	Name                      string `json:"name" yaml:"name"`
+	Host                      string `json:"host" yaml:"host"`
+	IPVersion                 string `json:"ipVersion" yaml:"ipVersion"`
+	PingCount                 int    `json:"pingCount" yaml:"pingCount"`
+	PacketSize                int    `json:"packetSize" yaml:"packetSize"`
+	Interval                  string `json:"interval" yaml:"interval"`
+	CheckSites                []int  `json:"checkSites" yaml:"checkSites"`
+	RunTraceroute             string `json:"runTraceroute" yaml:"runTraceroute"`
+	Note                      string `json:"note" yaml:"note"`
+	UserID                    int    `json:"userId" yaml:"userId"`
+	MonitorIntervalPolicy     string `json:"monitorIntervalPolicy" yaml:"monitorIntervalPolicy"`
+	NotificationGroups        []int  `json:"notificationGroups" yaml:"notificationGroups"`
+	ScheduleID                int    `json:"scheduleId" yaml:"scheduleId"`
+	NotificationReportTimeout int    `json:"notificationReportTimeout" yaml:"notificationReportTimeout"`
+	VerificationPolicy        string `json:"verificationPolicy" yaml:"verificationPolicy"`
+}
+
+func (ac *SonarICMPCheck) GetResource() interface{} {
+	return ac
+}
+
+func (ac *SonarICMPCheck) GetResourceID() string {
+	return ac.Name
+}
+
+func (ac *SonarICMPCheck) GetConstellixID() int {
+	return ac.ID
+}
+
+func (ac *SonarICMPCheck) SyncResourceDelete(constellixID int) error {
+	logger.Printf("  removing resource %q\n", ac.GetResourceID())
+	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "icmp", fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	body, err := makeSimpleAPIRequest("DELETE", endpoint, nil, 202)
+	if err != nil {
+		logger.Println("  unexpected response. Details: " + string(body))
+		return fmt.Errorf("unable to delete Sonar ICMP checks: %w", err)
+	}
+	return nil
+}
+
+type ExpectedSonarICMPCheck struct {
+	// Mapping of defined fields from parsed data to struct Field Names
+	definedFieldsMap map[string]string
+	// List of immutable fields which can't be updated via API
+	immutableFields []string
+	// List of mandatory fields which must be defined, used for validation
+	mandatoryFields []string
+	SonarICMPCheck
+}
+
+// UnmarshalYAML unmarshals the mesage and stores original fields
+func (ex *ExpectedSonarICMPCheck) UnmarshalYAML(value *yaml.Node) error {
+	ex.immutableFields = []string{"host", "ipVersion"}
+	ex.mandatoryFields = []string{"name", "host", "ipVersion", "interval", "checkSites"}
+
+	// Unmarshall data into SonarICMPCheck struct
+	var s SonarICMPCheck
+	err := value.Decode(&s)
+	if err != nil {
+		return err
+	}
+	ex.SonarICMPCheck = s
+
+	// Save specified fields
+	dm := make(map[string]interface{})
+	err = value.Decode(&dm)
+	if err != nil {
+		return err
+	}
+
+	definedFields := make([]string, len(dm))
+	i := 0
+	for k := range dm {
+		definedFields[i] = k
+		i++
+	}
+	ex.definedFieldsMap = getFieldNamesMap(&ex.SonarICMPCheck, "yaml", definedFields...)
+	return nil
+}
+
+// Validate performs simple validation of user provided data
+func (ex *ExpectedSonarICMPCheck) Validate() error {
+	// Validate that all mandatory fields are present
+	for _, f := range ex.mandatoryFields {
+		if !slices.Contains(maps.Keys(ex.definedFieldsMap), f) {
+			return fmt.Errorf("%s: mandatory field %q is not defined", ex.Name, f)
+		}
+	}
+	return nil
+}
+
+// GetDefinedStructFieldNames returns list of defined struct fields from local configuration
+func (ex *ExpectedSonarICMPCheck) GetDefinedStructFieldNames() []string {
+	return maps.Values(ex.definedFieldsMap)
+}
+
+// GetImmutableStructFields returns list of immutable struct fields
+func (ex *ExpectedSonarICMPCheck) GetImmutableStructFields() []string {
+	var imf []string
+	for k, v := range ex.definedFieldsMap {
+		if slices.Contains(ex.immutableFields, k) {
+			imf = append(imf, v)
+		}
+	}
+	return imf
+}
+
+func (ex *ExpectedSonarICMPCheck) GetResource() interface{} {
+	return ex.SonarICMPCheck
+}
+
+func (ex *ExpectedSonarICMPCheck) GetResourceID() string {
+	return ex.Name
+}
+
+func (ex *ExpectedSonarICMPCheck) SyncResourceUpdate(constellixID int) error {
+	logger.Printf("  updating resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "icmp", fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), ex.immutableFields)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	body, err := makeSimpleAPIRequest("PUT", endpoint, payloadReader, 200)
+	if err != nil {
+		logger.Println("  unexpected response. Details: " + string(body))
+		return fmt.Errorf("unable to update Sonar ICMP checks: %w", err)
+	}
+	return nil
+}
+
+func (ex *ExpectedSonarICMPCheck) SyncResourceCreate() error {
+	logger.Printf("  creating new resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "icmp")
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), nil)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	body, err := makeSimpleAPIRequest("POST", endpoint, payloadReader, 201)
+	if err != nil {
+		logger.Println("  unexpected response. Details: " + string(body))
+		return fmt.Errorf("unable to create Sonar ICMP checks: %w", err)
+	}
+	return nil
+}
+
+// GetSonarICMPChecks returns active Sonar Checks
+// We also cache this response to avoid making API calls when parsing configuration
+// files (@sonar,icmp:... syntax)
+func GetSonarICMPChecks() ([]*SonarICMPCheck, error) {
+	// Fetch ICMP checks
+	if logLevel > 0 {
+		logger.Println("Retrieving Sonar ICMP Checks...")
+	}
+	if len(cachedSonarICMPChecks) > 0 {
+		if logLevel > 0 {
+			logger.Println("  using cached Sonar ICMP Checks")
+		}
+		return cachedSonarICMPChecks, nil
+	}
+	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "icmp")
+	if err != nil {
+		return nil, err
+	}
+	data, err := makeSimpleAPIRequest("GET", endpoint, nil, 200)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve Sonar ICMP checks: %w", err)
+	}
+
+	checks := make([]*SonarICMPCheck, 0)
+	err = json.Unmarshal(data, &checks)
+	if err != nil {
+		return nil, err
+	}
+
+	cachedSonarICMPChecks = checks
+	return checks, nil
+}
+
+// GetSonarICMPCheckStatus returns active Sonar Check status using runtime endpoint
+func GetSonarICMPCheckStatus(id int) (ResourceRuntimeStatus, error) {
+	return getSonarCheckStatus("icmp", id)
+}
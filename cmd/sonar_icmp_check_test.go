@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetSonarCheckID_icmp(t *testing.T) {
+	originalCache := cachedSonarICMPChecks
+	defer func() { cachedSonarICMPChecks = originalCache }()
+	cachedSonarICMPChecks = []*SonarICMPCheck{{ID: 12, Name: "ping-prod", Host: "1.1.1.1"}}
+
+	id, host, err := getSonarCheckID("@sonar,icmp:ping-prod")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if id != 12 || host != "1.1.1.1" {
+		t.Errorf("want 12 and %q, got %d and %q", "1.1.1.1", id, host)
+	}
+}
+
+func TestGetSonarICMPCheckStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/icmp/12/status" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"status":"UP"}`))
+	}))
+	defer ts.Close()
+
+	originalSonarRESTAPIBaseURL := sonarRESTAPIBaseURL
+	defer func() {
+		sonarRESTAPIBaseURL = originalSonarRESTAPIBaseURL
+	}()
+	sonarRESTAPIBaseURL = ts.URL
+
+	status, err := GetSonarICMPCheckStatus(12)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if status != StatusUp {
+		t.Errorf("want %q, got %q", StatusUp, status)
+	}
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Example:
+//
+//	{
+//	  "id": 83648,
+//	  "name": "ssl-test",
+//	  "host": "159.69.18.28",
+//	  "port": 443,
+//	  "ipVersion": "IPV4",
+//	  "sni": "example.com",
+//	  "daysBeforeExpiry": 14,
+//	  "note": "",
+//	  "userId": 300003895,
+//	  "interval": "DAY",
+//	  "monitorIntervalPolicy": "PARALLEL",
+//	  "checkSites": [
+//	    4
+//	  ],
+//	  "notificationGroups": [],
+//	  "scheduleId": 0,
+//	  "notificationReportTimeout": 1440,
+//	  "verificationPolicy": "SIMPLE"
+//	}
+type SonarSSLCheck struct {
+	ID                        int    `json:"id"`
+	Name                      string `json:"name" yaml:"name"`
+	Host                      string `json:"host" yaml:"host"`
+	IPVersion                 string `json:"ipVersion" yaml:"ipVersion"`
+	Port                      int    `json:"port" yaml:"port"`
+	SNI                       string `json:"sni" yaml:"sni"`
+	DaysBeforeExpiry          int    `json:"daysBeforeExpiry" yaml:"daysBeforeExpiry"` // Warn when certificate expires in less days
+	Interval                  string `json:"interval" yaml:"interval"`
+	CheckSites                []int  `json:"checkSites" yaml:"checkSites"`
+	Note                      string `json:"note" yaml:"note"`
+	UserID                    int    `json:"userId" yaml:"userId"`
+	MonitorIntervalPolicy     string `json:"monitorIntervalPolicy" yaml:"monitorIntervalPolicy"`
+	NotificationGroups        []int  `json:"notificationGroups" yaml:"notificationGroups"`
+	ScheduleID                int    `json:"scheduleId" yaml:"scheduleId"`
+	NotificationReportTimeout int    `json:"notificationReportTimeout" yaml:"notificationReportTimeout"`
+	VerificationPolicy        string `json:"verificationPolicy" yaml:"verificationPolicy"`
+}
+
+func (ac *SonarSSLCheck) GetResource() interface{} {
+	return ac
+}
+
+func (ac *SonarSSLCheck) GetResourceID() string {
+	return ac.Name
+}
+
+func (ac *SonarSSLCheck) GetConstellixID() int {
+	return ac.ID
+}
+
+func (ac *SonarSSLCheck) SyncResourceDelete(constellixID int) error {
+	logger.Printf("  removing resource %q\n", ac.GetResourceID())
+	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "ssl", fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	body, err := makeSimpleAPIRequest("DELETE", endpoint, nil, 202)
+	if err != nil {
+		logger.Println("  unexpected response. Details: " + string(body))
+		return fmt.Errorf("unable to delete Sonar SSL checks: %w", err)
+	}
+	return nil
+}
+
+type ExpectedSonarSSLCheck struct {
+	// Mapping of defined fields from parsed data to struct Field Names
+	definedFieldsMap map[string]string
+	// List of immutable fields which can't be updated via API
+	immutableFields []string
+	// List of mandatory fields which must be defined, used for validation
+	mandatoryFields []string
+	SonarSSLCheck
+}
+
+// UnmarshalYAML unmarshals the mesage and stores original fields
+func (ex *ExpectedSonarSSLCheck) UnmarshalYAML(value *yaml.Node) error {
+	ex.immutableFields = []string{"host", "ipVersion"}
+	ex.mandatoryFields = []string{"name", "host", "ipVersion", "port", "daysBeforeExpiry", "interval", "checkSites"}
+
+	// Unmarshall data into SonarSSLCheck struct
+	var s SonarSSLCheck
+	err := value.Decode(&s)
+	if err != nil {
+		return err
+	}
+	ex.SonarSSLCheck = s
+
+	// Save specified fields
+	dm := make(map[string]interface{})
+	err = value.Decode(&dm)
+	if err != nil {
+		return err
+	}
+
+	definedFields := make([]string, len(dm))
+	i := 0
+	for k := range dm {
+		definedFields[i] = k
+		i++
+	}
+	ex.definedFieldsMap = getFieldNamesMap(&ex.SonarSSLCheck, "yaml", definedFields...)
+	return nil
+}
+
+// Validate performs simple validation of user provided data
+func (ex *ExpectedSonarSSLCheck) Validate() error {
+	// Validate that all mandatory fields are present
+	for _, f := range ex.mandatoryFields {
+		if !slices.Contains(maps.Keys(ex.definedFieldsMap), f) {
+			return fmt.Errorf("%s: mandatory field %q is not defined", ex.Name, f)
+		}
+	}
+	return nil
+}
+
+// GetDefinedStructFieldNames returns list of defined struct fields from local configuration
+func (ex *ExpectedSonarSSLCheck) GetDefinedStructFieldNames() []string {
+	return maps.Values(ex.definedFieldsMap)
+}
+
+// GetImmutableStructFields returns list of immutable struct fields
+func (ex *ExpectedSonarSSLCheck) GetImmutableStructFields() []string {
+	var imf []string
+	for k, v := range ex.definedFieldsMap {
+		if slices.Contains(ex.immutableFields, k) {
+			imf = append(imf, v)
+		}
+	}
+	return imf
+}
+
+func (ex *ExpectedSonarSSLCheck) GetResource() interface{} {
+	return ex.SonarSSLCheck
+}
+
+func (ex *ExpectedSonarSSLCheck) GetResourceID() string {
+	return ex.Name
+}
+
+func (ex *ExpectedSonarSSLCheck) SyncResourceUpdate(constellixID int) error {
+	logger.Printf("  updating resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "ssl", fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), ex.immutableFields)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	body, err := makeSimpleAPIRequest("PUT", endpoint, payloadReader, 200)
+	if err != nil {
+		logger.Println("  unexpected response. Details: " + string(body))
+		return fmt.Errorf("unable to update Sonar SSL checks: %w", err)
+	}
+	return nil
+}
+
+func (ex *ExpectedSonarSSLCheck) SyncResourceCreate() error {
+	logger.Printf("  creating new resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "ssl")
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), nil)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	body, err := makeSimpleAPIRequest("POST", endpoint, payloadReader, 201)
+	if err != nil {
+		logger.Println("  unexpected response. Details: " + string(body))
+		return fmt.Errorf("unable to create Sonar SSL checks: %w", err)
+	}
+	return nil
+}
+
+// GetSonarSSLChecks returns active Sonar Checks
+func GetSonarSSLChecks() ([]*SonarSSLCheck, error) {
+	// Fetch SSL checks
+	if logLevel > 0 {
+		logger.Println("Retrieving Sonar SSL Checks...")
+	}
+	if cachedSonarSSLChecks != nil {
+		if logLevel > 0 {
+			logger.Println("  using cached Sonar SSL Checks")
+		}
+		return cachedSonarSSLChecks, nil
+	}
+	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "ssl")
+	if err != nil {
+		return nil, err
+	}
+	data, err := makeSimpleAPIRequest("GET", endpoint, nil, 200)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve Sonar SSL checks: %w", err)
+	}
+
+	checks := make([]*SonarSSLCheck, 0)
+	err = json.Unmarshal(data, &checks)
+	if err != nil {
+		return nil, err
+	}
+
+	cachedSonarSSLChecks = checks
+	return checks, nil
+}
+
+// SSLRuntimeStatus is a runtime status of SSL check which also contains the
+// expiration date of the certificate. The date is either Unix time in
+// milliseconds or RFC 3339 string
+type SSLRuntimeStatus struct {
+	RuntimeStatus
+	ExpirationDate interface{} `json:"expirationDate"`
+}
+
+// DaysRemaining returns the number of days until the certificate expires
+func (s *SSLRuntimeStatus) DaysRemaining(now time.Time) (int, error) {
+	if s.ExpirationDate == nil {
+		return 0, fmt.Errorf("certificate expiration date is unknown")
+	}
+	expiresAt, err := parseSonarTime(s.ExpirationDate)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse certificate expiration date: %s", err)
+	}
+	return int(math.Floor(expiresAt.Sub(now).Hours() / 24)), nil
+}
+
+// GetSonarSSLCheckStatus returns active Sonar Check status using runtime endpoint
+// and the number of days until the certificate expires
+func GetSonarSSLCheckStatus(id int) (ResourceRuntimeStatus, string, error) {
+	status := SSLRuntimeStatus{RuntimeStatus: RuntimeStatus{Status: "unknown"}}
+	err := readSonarCheckRuntime("ssl", id, &status)
+	if err != nil {
+		return "", "", err
+	}
+	days, err := status.DaysRemaining(time.Now())
+	if err != nil {
+		return status.Status, err.Error(), nil
+	}
+	return status.Status, fmt.Sprintf("%d days remaining", days), nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSSLRuntimeStatus_DaysRemaining(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expirationDate interface{}
+		want           int
+	}{
+		{"2024-01-31T12:00:00Z", 30},
+		{float64(now.Add(36 * time.Hour).UnixMilli()), 1},
+		{"2023-12-31T00:00:00Z", -2},
+	}
+	for _, tt := range tests {
+		status := SSLRuntimeStatus{ExpirationDate: tt.expirationDate}
+		got, err := status.DaysRemaining(now)
+		if err != nil {
+			t.Errorf("DaysRemaining(%v) error = %v", tt.expirationDate, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("DaysRemaining(%v) = %d, want %d", tt.expirationDate, got, tt.want)
+		}
+	}
+}
+
+func TestGetSonarCheckID_ssl(t *testing.T) {
+	originalCache := cachedSonarSSLChecks
+	defer func() { cachedSonarSSLChecks = originalCache }()
+	cachedSonarSSLChecks = []*SonarSSLCheck{{ID: 13, Name: "cert-prod", Host: "192.0.2.5"}}
+
+	id, host, err := getSonarCheckID("@sonar,ssl:cert-prod")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if id != 13 || host != "192.0.2.5" {
+		t.Errorf("want 13 and %q, got %d and %q", "192.0.2.5", id, host)
+	}
+}
+
+func TestGetSonarSSLCheckStatus(t *testing.T) {
+	expiresAt := time.Now().Add(10*24*time.Hour + time.Hour).UTC().Format(time.RFC3339)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ssl/3/status" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"status":"UP","expirationDate":"` + expiresAt + `"}`))
+	}))
+	defer ts.Close()
+
+	originalSonarRESTAPIBaseURL := sonarRESTAPIBaseURL
+	defer func() {
+		sonarRESTAPIBaseURL = originalSonarRESTAPIBaseURL
+	}()
+	sonarRESTAPIBaseURL = ts.URL
+
+	status, details, err := GetSonarSSLCheckStatus(3)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if status != StatusUp {
+		t.Errorf("want %q, got %q", StatusUp, status)
+	}
+	if details != "10 days remaining" {
+		t.Errorf("want %q, got %q", "10 days remaining", details)
+	}
+}
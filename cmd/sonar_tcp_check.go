@@ -75,7 +75,7 @@ func (ac *SonarTCPCheck) SyncResourceDelete(constellixID int) error {
 	body, err := makeSimpleAPIRequest("DELETE", endpoint, nil, 202)
 	if err != nil {
 		logger.Println("  unexpected response. Details: " + string(body))
-		return fmt.Errorf("unable to delete Sonar TCP checks: %s", err)
+		return fmt.Errorf("unable to delete Sonar TCP checks: %w", err)
 	}
 	return nil
 }
@@ -169,7 +169,7 @@ func (ex *ExpectedSonarTCPCheck) SyncResourceUpdate(constellixID int) error {
 	body, err := makeSimpleAPIRequest("PUT", endpoint, payloadReader, 200)
 	if err != nil {
 		logger.Println("  unexpected response. Details: " + string(body))
-		return fmt.Errorf("unable to update Sonar TCP checks: %s", err)
+		return fmt.Errorf("unable to update Sonar TCP checks: %w", err)
 	}
 	return nil
 }
@@ -188,7 +188,7 @@ func (ex *ExpectedSonarTCPCheck) SyncResourceCreate() error {
 	body, err := makeSimpleAPIRequest("POST", endpoint, payloadReader, 201)
 	if err != nil {
 		logger.Println("  unexpected response. Details: " + string(body))
-		return fmt.Errorf("unable to create Sonar TCP checks: %s", err)
+		return fmt.Errorf("unable to create Sonar TCP checks: %w", err)
 	}
 	return nil
 }
@@ -199,13 +199,19 @@ func GetSonarTCPChecks() ([]*SonarTCPCheck, error) {
 	if logLevel > 0 {
 		logger.Println("Retrieving Sonar TCP Checks...")
 	}
+	if cachedSonarTCPChecks != nil {
+		if logLevel > 0 {
+			logger.Println("  using cached Sonar TCP Checks")
+		}
+		return cachedSonarTCPChecks, nil
+	}
 	endpoint, err := url.JoinPath(sonarRESTAPIBaseURL, "tcp")
 	if err != nil {
 		return nil, err
 	}
 	data, err := makeSimpleAPIRequest("GET", endpoint, nil, 200)
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve Sonar TCP checks: %s", err)
+		return nil, fmt.Errorf("unable to retrieve Sonar TCP checks: %w", err)
 	}
 
 	checks := make([]*SonarTCPCheck, 0)
@@ -213,5 +219,12 @@ func GetSonarTCPChecks() ([]*SonarTCPCheck, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	cachedSonarTCPChecks = checks
 	return checks, nil
 }
+
+// GetSonarTCPCheckStatus returns active Sonar Check status using runtime endpoint
+func GetSonarTCPCheckStatus(id int) (ResourceRuntimeStatus, error) {
+	return getSonarCheckStatus("tcp", id)
+}
@@ -2,51 +2,104 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
 )
 
-func Sync(expectedCollection, activeCollection []ResourceMatcher, doit, remove bool, title string) error {
-	report := table.NewWriter()
+// PlanEntry represents planned action for a single resource
+type PlanEntry struct {
+	Action         ResourceAction `json:"action" yaml:"action"`
+	ResourceID     string         `json:"resourceId" yaml:"resourceId"`
+	ConstellixID   int            `json:"constellixId,omitempty" yaml:"constellixId,omitempty"`
+	Diffs          []*FieldDiff   `json:"diffs,omitempty" yaml:"diffs,omitempty"`
+	Checksum       string         `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	ActiveChecksum string         `json:"activeChecksum,omitempty" yaml:"activeChecksum,omitempty"`
+	expected       IExpectedResource
+	active         IActiveResource
+}
 
-	toDelete := []IActiveResource{}
-	toUpdate := map[IExpectedResource]int{}
-	toCreate := []IExpectedResource{}
+// SyncPlan contains actions which bring active resources in line with the
+// expected ones
+type SyncPlan struct {
+	Title   string       `json:"title" yaml:"title"`
+	Entries []*PlanEntry `json:"entries" yaml:"entries"`
+	// Domain and its DNS records the plan of DNS records was made with. The
+	// records are saved to a snapshot before changes are applied
+	dnsDomain  *DNSDomain
+	dnsRecords []*DNSRecord
+}
 
-	if reportToTestBuffer {
-		// Skip header in tests
-		report.SetOutputMirror(testBuffer)
-	} else {
-		report.SetOutputMirror(os.Stdout)
-		if title != "" {
-			report.SetTitle(title)
-		}
-		report.AppendHeader(table.Row{"Action", "Resource", "Details"})
-	}
+// PlanSummary contains the total number of planned changes
+type PlanSummary struct {
+	Delete int `json:"delete" yaml:"delete"`
+	Update int `json:"update" yaml:"update"`
+	Create int `json:"create" yaml:"create"`
+}
+
+// PlanDocument is a machine-readable representation of planned changes
+type PlanDocument struct {
+	Config  string      `json:"config,omitempty" yaml:"config,omitempty"`
+	Remove  bool        `json:"remove,omitempty" yaml:"remove,omitempty"`
+	Plans   []*SyncPlan `json:"plans" yaml:"plans"`
+	Summary PlanSummary `json:"summary" yaml:"summary"`
+	// Error is set when the command failed, the plans might be incomplete
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Formats of planned changes output
+const planOutputTable = "table"
+const planOutputJSON = "json"
+const planOutputYAML = "yaml"
+
+var supportedPlanOutputFormats = []string{planOutputTable, planOutputJSON, planOutputYAML}
+
+// planOutputFormat is the format of planned changes output. In machine-readable
+// formats, reported plans are collected and written as a single document by
+// writePlanOutput, and logs are redirected to stderr
+var planOutputFormat = planOutputTable
+var reportedPlans []*SyncPlan
+var planOutputWritten bool
+
+// Exit code of sync commands with --detailed-exitcode when there are changes
+const exitCodeChangesPending = 2
+
+// detailedExitCode makes sync commands exit with exitCodeChangesPending if any
+// of the planned changes were not applied (changesPending)
+var detailedExitCode bool
+var changesPending bool
+
+// planSync compares expected and active collections and returns the list of
+// actions required to sync them. Nothing is changed in Constellix
+func planSync(expectedCollection, activeCollection []ResourceMatcher, title string) (*SyncPlan, error) {
+	plan := &SyncPlan{Title: title}
 
 	// Check if anything needs to be deleted first
 	for _, a := range activeCollection {
 		activeResource := a.(IActiveResource)
 		if logLevel > 0 {
-			fmt.Printf("Inspecting %q...\n", activeResource.GetResourceID())
+			logger.Printf("Inspecting %q...\n", activeResource.GetResourceID())
 		}
 		matched := getMatchingResource(activeResource, expectedCollection)
 		if matched == nil {
 			if logLevel > 0 {
-				fmt.Printf("  status: %s\n", ActionDelete)
+				logger.Printf("  status: %s\n", ActionDelete)
 			}
-			report.AppendRow(table.Row{
-				colorAction(ActionDelete),
-				activeResource.GetResourceID(),
-				fmt.Sprintf("Resource ID %d", activeResource.GetConstellixID()),
+			plan.Entries = append(plan.Entries, &PlanEntry{
+				Action:       ActionDelete,
+				ResourceID:   activeResource.GetResourceID(),
+				ConstellixID: activeResource.GetConstellixID(),
+				active:       activeResource,
 			})
-			report.AppendSeparator()
-			toDelete = append(toDelete, activeResource)
 		}
 	}
 
@@ -65,52 +118,122 @@ func Sync(expectedCollection, activeCollection []ResourceMatcher, doit, remove b
 
 		action, diffs, err := Compare(expectedResource, activeResource)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if logLevel > 0 {
 			logger.Printf("  status: %s\n", action)
 		}
-		if len(diffs) == 0 {
-			report.AppendRow(table.Row{
-				colorAction(action), expectedResource.GetResourceID(), "",
-			})
-		} else {
-			for idx, diff := range diffs {
+		entry := &PlanEntry{
+			Action:     action,
+			ResourceID: expectedResource.GetResourceID(),
+			Diffs:      diffs,
+			expected:   expectedResource,
+			active:     activeResource,
+		}
+		switch action {
+		case ActionOK, ActionUpate, ActionCreate:
+		case ActionError:
+			return nil, fmt.Errorf("unable to plan changes for %q", expectedResource.GetResourceID())
+		default:
+			return nil, fmt.Errorf("unhandled action %q", action)
+		}
+		if activeResource != nil {
+			entry.ConstellixID = activeResource.GetConstellixID()
+		}
+		plan.Entries = append(plan.Entries, entry)
+	}
+	return plan, nil
+}
+
+// toDelete returns active resources which need to be removed
+func (p *SyncPlan) toDelete() []IActiveResource {
+	resources := []IActiveResource{}
+	for _, entry := range p.Entries {
+		if entry.Action == ActionDelete {
+			resources = append(resources, entry.active)
+		}
+	}
+	return resources
+}
+
+// toUpdate returns expected resources which need to be updated, mapped to
+// the ID of the active resource in Constellix
+func (p *SyncPlan) toUpdate() map[IExpectedResource]int {
+	resources := map[IExpectedResource]int{}
+	for _, entry := range p.Entries {
+		if entry.Action == ActionUpate {
+			resources[entry.expected] = entry.ConstellixID
+		}
+	}
+	return resources
+}
+
+// toCreate returns expected resources which need to be created
+func (p *SyncPlan) toCreate() []IExpectedResource {
+	resources := []IExpectedResource{}
+	for _, entry := range p.Entries {
+		if entry.Action == ActionCreate {
+			resources = append(resources, entry.expected)
+		}
+	}
+	return resources
+}
+
+// appendToReport adds rows describing the plan to the report. If withTitle is
+// set, the first column of the plan's first row contains the title of the
+// plan (combined reports)
+func (p *SyncPlan) appendToReport(report table.Writer, withTitle bool) {
+	first := true
+	appendRow := func(cells ...interface{}) {
+		if withTitle {
+			title := ""
+			if first {
+				title = p.Title
+			}
+			cells = append([]interface{}{title}, cells...)
+		}
+		first = false
+		report.AppendRow(cells)
+	}
+	for _, entry := range p.Entries {
+		switch {
+		case entry.Action == ActionDelete:
+			appendRow(
+				colorAction(entry.Action),
+				entry.ResourceID,
+				fmt.Sprintf("Resource ID %d", entry.ConstellixID),
+			)
+		case len(entry.Diffs) == 0:
+			appendRow(colorAction(entry.Action), entry.ResourceID, "")
+		default:
+			for idx, diff := range entry.Diffs {
 				if idx == 0 {
-					report.AppendRow(table.Row{
-						colorAction(action), expectedResource.GetResourceID(), diff.String(),
-					})
+					appendRow(colorAction(entry.Action), entry.ResourceID, diff.String())
 				} else {
-					report.AppendRow(table.Row{
-						"", "", diff.String(),
-					})
+					appendRow("", "", diff.String())
 				}
 			}
 		}
 		report.AppendSeparator()
-		switch action {
-		case ActionOK:
-		case ActionUpate:
-			toUpdate[expectedResource] = activeResource.GetConstellixID()
-		case ActionCreate:
-			toCreate = append(toCreate, expectedResource)
-		case ActionError:
-			report.Render()
-			os.Exit(1)
-		default:
-			return fmt.Errorf("unhandled action %q", action)
-		}
 	}
+}
 
-	printReport(report)
-	logger.Printf("SUMMARY: %d to delete, %d to update, %d to create\n", len(toDelete), len(toUpdate), len(toCreate))
+func Sync(expectedCollection, activeCollection []ResourceMatcher, doit, remove bool, title string) error {
+	plan, err := planSync(expectedCollection, activeCollection, title)
+	if err != nil {
+		return err
+	}
+
+	reportPlans(title, false, plan)
+	logSyncSummary(plan)
+	markPendingChanges(doit, plan)
 
 	if doit {
-		if !remove && len(toDelete) > 0 {
+		if !remove && len(plan.toDelete()) > 0 {
 			return fmt.Errorf("resource deletion is not allowed. Use --remove flag to allow it")
 		}
 		logger.Println("Syncing changes...")
-		err := syncChanges(toDelete, toUpdate, toCreate)
+		err := syncChanges(plan.toDelete(), plan.toUpdate(), plan.toCreate())
 		if err != nil {
 			return err
 		}
@@ -118,6 +241,223 @@ func Sync(expectedCollection, activeCollection []ResourceMatcher, doit, remove b
 	return nil
 }
 
+// newSyncReport returns a table writer for sync reports. Combined reports
+// (withTitle) have an extra column with the title of each plan
+func newSyncReport(title string, withTitle bool) table.Writer {
+	report := table.NewWriter()
+	if reportToTestBuffer {
+		// Skip header in tests
+		report.SetOutputMirror(testBuffer)
+	} else {
+		report.SetOutputMirror(os.Stdout)
+		if title != "" {
+			report.SetTitle(title)
+		}
+		if withTitle {
+			report.AppendHeader(table.Row{"Type", "Action", "Resource", "Details"})
+		} else {
+			report.AppendHeader(table.Row{"Action", "Resource", "Details"})
+		}
+	}
+	return report
+}
+
+// summarizePlans returns the total number of planned changes
+func summarizePlans(plans ...*SyncPlan) PlanSummary {
+	summary := PlanSummary{}
+	for _, plan := range plans {
+		for _, entry := range plan.Entries {
+			switch entry.Action {
+			case ActionDelete:
+				summary.Delete++
+			case ActionUpate:
+				summary.Update++
+			case ActionCreate:
+				summary.Create++
+			}
+		}
+	}
+	return summary
+}
+
+// logSyncSummary prints the total number of planned changes
+func logSyncSummary(plans ...*SyncPlan) {
+	summary := summarizePlans(plans...)
+	logger.Printf("SUMMARY: %d to delete, %d to update, %d to create\n", summary.Delete, summary.Update, summary.Create)
+}
+
+// setupPlanOutput configures the format of planned changes output and the
+// exit code from the flags. Call resetPlanOutput when the command is done
+func setupPlanOutput(cmd *cobra.Command) error {
+	format, err := cmd.Flags().GetString("output-format")
+	if err != nil {
+		return err
+	}
+	detailedExitCode, err = cmd.Flags().GetBool("detailed-exitcode")
+	if err != nil {
+		return err
+	}
+	changesPending = false
+	if !slices.Contains(supportedPlanOutputFormats, format) {
+		return fmt.Errorf("unsupported output format: got %q, want one of %q", format, supportedPlanOutputFormats)
+	}
+	planOutputFormat = format
+	reportedPlans = nil
+	planOutputWritten = false
+	if format != planOutputTable {
+		// Keep stdout clean for the plan document
+		logger.SetOutput(os.Stderr)
+	}
+	return nil
+}
+
+// resetPlanOutput restores the default output of planned changes
+func resetPlanOutput() {
+	planOutputFormat = planOutputTable
+	reportedPlans = nil
+	planOutputWritten = false
+	detailedExitCode = false
+	changesPending = false
+	logger.SetOutput(os.Stdout)
+}
+
+// reportPlans renders plans as a table or, in machine-readable formats, keeps
+// them until writePlanOutput is called
+func reportPlans(title string, withTitle bool, plans ...*SyncPlan) {
+	if planOutputFormat != planOutputTable {
+		reportedPlans = append(reportedPlans, plans...)
+		return
+	}
+	report := newSyncReport(title, withTitle)
+	for _, plan := range plans {
+		plan.appendToReport(report, withTitle)
+	}
+	printReport(report)
+}
+
+// markPendingChanges sets changesPending if the plans contain changes which
+// are not going to be applied. Failed changes make the command fail instead
+func markPendingChanges(applied bool, plans ...*SyncPlan) {
+	if !applied && summarizePlans(plans...) != (PlanSummary{}) {
+		changesPending = true
+	}
+}
+
+// writePlanOutput writes all reported plans as a single document in the
+// machine-readable format. If the command failed (runErr), the error is
+// included in the document. Nothing is done for the table format
+func writePlanOutput(runErr error) error {
+	var data []byte
+	var err error
+	document := PlanDocument{Plans: reportedPlans, Summary: summarizePlans(reportedPlans...)}
+	if document.Plans == nil {
+		document.Plans = []*SyncPlan{}
+	}
+	if runErr != nil {
+		document.Error = runErr.Error()
+	}
+	switch planOutputFormat {
+	case planOutputTable:
+		return nil
+	case planOutputJSON:
+		data, err = json.MarshalIndent(document, "", "  ")
+		data = append(data, '\n')
+	case planOutputYAML:
+		data, err = yaml.Marshal(document)
+	}
+	if err != nil {
+		return err
+	}
+	planOutputWritten = true
+	if reportToTestBuffer {
+		_, err = testBuffer.Write(data)
+	} else {
+		_, err = os.Stdout.Write(data)
+	}
+	return err
+}
+
+// closePlanOutput writes the plan document with the error of a command which
+// failed before finishSync, so machine-readable output is always a complete
+// document, and restores the default output. Defer it with the command error
+func closePlanOutput(err error) {
+	if err != nil && !planOutputWritten {
+		writeErr := writePlanOutput(err)
+		if writeErr != nil {
+			logger.Printf("unable to write plan document: %s\n", writeErr)
+		}
+	}
+	resetPlanOutput()
+}
+
+// finishSync reports results of changes applied with --keep-going, writes the
+// plan document in machine-readable formats and sets the exit code of the
+// application. Call it when the sync command succeeds
+func finishSync() error {
+	err := reportSyncResults()
+	writeErr := writePlanOutput(err)
+	if err != nil {
+		return err
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	if detailedExitCode && changesPending {
+		exitCode = exitCodeChangesPending
+	}
+	return nil
+}
+
+// addSyncFlags adds flags shared by all sync commands
+func addSyncFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
+	cmd.PersistentFlags().Bool("doit", false, "apply planned changes")
+	cmd.PersistentFlags().Bool("remove", false, "remove resources which are not present in configuration file")
+	addApplyFlags(cmd)
+	addBackupFlags(cmd)
+	addPlanOutputFlags(cmd)
+}
+
+// addApplyFlags adds flags which control how changes are applied
+func addApplyFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().IntVar(
+		&syncParallelism, "parallelism", 1, "maximum number of changes which are applied concurrently",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&syncKeepGoing, "keep-going", false, "attempt every change even if some of them fail and report all failures",
+	)
+}
+
+// addPlanOutputFlags adds flags which configure the output of planned changes
+func addPlanOutputFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringP(
+		"output-format", "f", planOutputTable,
+		fmt.Sprintf("format of planned changes, one of %q", supportedPlanOutputFormats),
+	)
+	cmd.PersistentFlags().Bool(
+		"detailed-exitcode", false,
+		"exit with 0 when there are no changes, 2 when there are changes and 1 on error",
+	)
+}
+
+// logSyncHint prints a hint about flags which are required to apply changes
+func logSyncHint(doit, remove bool) {
+	var message string
+	if !doit {
+		message += "apply changes by passing --doit flag"
+	}
+	if !remove {
+		if message != "" {
+			message += "; "
+		}
+		message += "allow removing of resources by passing --remove flag"
+	}
+	if message == "" {
+		message = "done"
+	}
+	logger.Println(message)
+}
+
 func printReport(report table.Writer) {
 	if reportToTestBuffer {
 		// Skip header in tests to simplify testing
@@ -127,46 +467,87 @@ func printReport(report table.Writer) {
 	}
 }
 
+// syncParallelism is the maximum number of concurrent API requests made by
+// syncChanges
+var syncParallelism = 1
+
+// syncKeepGoing makes syncChanges attempt every change even if some of them
+// fail. Results of all changes are collected in syncResults and reported by
+// reportSyncResults
+var syncKeepGoing bool
+var syncResults []*SyncResult
+var syncResultsMu sync.Mutex
+
+// failedSyncChanges counts changes which failed, with or without syncKeepGoing
+var failedSyncChanges int
+
+// createdSyncResources counts resources which were created successfully
+var createdSyncResources int
+
+// SyncResult is the result of a single applied change
+type SyncResult struct {
+	Action     ResourceAction
+	ResourceID string
+	Err        error
+}
+
+// syncChanges applies changes in order: deletes, updates and then creates.
+// Independent operations of each step run concurrently (syncParallelism)
 func syncChanges(toDelete []IActiveResource, toUpdate map[IExpectedResource]int, toCreate []IExpectedResource) error {
+	if syncParallelism < 1 {
+		return fmt.Errorf("parallelism must be at least 1, got %d", syncParallelism)
+	}
+
 	// First, we delete resources
 	// If the resource is DNSRecord, we must first remove the ones with geoproximities.
 	// They will not end with " 0)"
-	// Note: the sorting is very simple and might affect other resources
+	// Note: the grouping is very simple and might affect other resources
 	sort.Slice(toDelete, func(i, j int) bool {
-		iEndsWithZero := strings.HasSuffix(toDelete[i].GetResourceID(), " 0)")
-		jEndsWithZero := strings.HasSuffix(toDelete[j].GetResourceID(), " 0)")
-
-		// If both end with " 0)" or both don't, sort by the resource ID
-		if iEndsWithZero == jEndsWithZero {
-			return toDelete[i].GetResourceID() < toDelete[j].GetResourceID()
-		}
-
-		// If i ends with " 0)" and j doesn't, i should come after j
-		if iEndsWithZero && !jEndsWithZero {
-			return false
-		}
-
-		// If j ends with " 0)" and i doesn't, i should come before j
-		return true
+		return toDelete[i].GetResourceID() < toDelete[j].GetResourceID()
 	})
+	deleteGroups := [][]IActiveResource{{}, {}}
 	for _, resource := range toDelete {
-		err := resource.SyncResourceDelete(resource.GetConstellixID())
+		if isDefaultDNSRecordID(resource.GetResourceID()) {
+			deleteGroups[1] = append(deleteGroups[1], resource)
+		} else {
+			deleteGroups[0] = append(deleteGroups[0], resource)
+		}
+	}
+	for _, group := range deleteGroups {
+		err := runConcurrently(len(group), func(idx int) error {
+			return recordSyncResult(ActionDelete, group[idx], group[idx].SyncResourceDelete(group[idx].GetConstellixID()))
+		})
 		if err != nil {
 			return err
 		}
 	}
 
 	// Then, we update resources
-	for resource, constellixID := range toUpdate {
-		err := resource.SyncResourceUpdate(constellixID)
-		if err != nil {
-			return err
-		}
+	updates := maps.Keys(toUpdate)
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].GetResourceID() < updates[j].GetResourceID()
+	})
+	err := runConcurrently(len(updates), func(idx int) error {
+		return recordSyncResult(ActionUpate, updates[idx], updates[idx].SyncResourceUpdate(toUpdate[updates[idx]]))
+	})
+	if err != nil {
+		return err
 	}
 
-	// Finally, we create resources
+	// Finally, we create resources. In reverse order of deletion, DNS records
+	// without geoproximities are created first
+	createGroups := [][]IExpectedResource{{}, {}}
 	for _, resource := range toCreate {
-		err := resource.SyncResourceCreate()
+		if isDefaultDNSRecordID(resource.GetResourceID()) {
+			createGroups[0] = append(createGroups[0], resource)
+		} else {
+			createGroups[1] = append(createGroups[1], resource)
+		}
+	}
+	for _, group := range createGroups {
+		err := runConcurrently(len(group), func(idx int) error {
+			return recordSyncResult(ActionCreate, group[idx], group[idx].SyncResourceCreate())
+		})
 		if err != nil {
 			return err
 		}
@@ -174,6 +555,120 @@ func syncChanges(toDelete []IActiveResource, toUpdate map[IExpectedResource]int,
 	return nil
 }
 
+// recordSyncResult keeps the result of the change when syncKeepGoing is set.
+// The error is returned only if the sync should stop
+func recordSyncResult(action ResourceAction, resource ResourceMatcher, err error) error {
+	syncResultsMu.Lock()
+	defer syncResultsMu.Unlock()
+	if err != nil {
+		failedSyncChanges++
+	} else if action == ActionCreate {
+		createdSyncResources++
+	}
+	if !syncKeepGoing {
+		return err
+	}
+	syncResults = append(syncResults, &SyncResult{Action: action, ResourceID: resource.GetResourceID(), Err: err})
+	return nil
+}
+
+// countFailedSyncChanges returns the number of changes which failed so far
+func countFailedSyncChanges() int {
+	syncResultsMu.Lock()
+	defer syncResultsMu.Unlock()
+	return failedSyncChanges
+}
+
+// countCreatedSyncResources returns the number of resources created so far
+func countCreatedSyncResources() int {
+	syncResultsMu.Lock()
+	defer syncResultsMu.Unlock()
+	return createdSyncResources
+}
+
+// reportSyncResults renders results of all changes applied with syncKeepGoing
+// and returns an error if any of them failed
+func reportSyncResults() error {
+	syncResultsMu.Lock()
+	results := syncResults
+	syncResults = nil
+	syncResultsMu.Unlock()
+	if !syncKeepGoing || len(results) == 0 {
+		return nil
+	}
+
+	report := table.NewWriter()
+	if reportToTestBuffer {
+		// Skip header in tests
+		report.SetOutputMirror(testBuffer)
+	} else {
+		// Logs are written to stderr when plan is printed in machine-readable format
+		report.SetOutputMirror(logger.Writer())
+		report.SetTitle("Results")
+		report.AppendHeader(table.Row{"Action", "Resource", "Result", "Details"})
+	}
+	failed := 0
+	for _, result := range results {
+		if result.Err == nil {
+			report.AppendRow(table.Row{result.Action, result.ResourceID, Green + "succeeded" + Reset, ""})
+			continue
+		}
+		failed++
+		details := result.Err.Error()
+		var apiErr *APIError
+		if errors.As(result.Err, &apiErr) && apiErr.Body != "" {
+			details += "\n" + apiErr.Body
+		}
+		report.AppendRow(table.Row{result.Action, result.ResourceID, Red + "failed" + Reset, details})
+	}
+	printReport(report)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d changes failed", failed, len(results))
+	}
+	return nil
+}
+
+// isDefaultDNSRecordID checks if the resource ID belongs to a DNS record
+// without geoproximity
+func isDefaultDNSRecordID(resourceID string) bool {
+	return strings.HasSuffix(resourceID, " 0)")
+}
+
+// runConcurrently calls fn for items 0..count-1 with at most syncParallelism
+// calls at a time. No new calls are started after the first error, which is
+// returned when all started calls are done
+func runConcurrently(count int, fn func(idx int) error) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	workers := make(chan struct{}, syncParallelism)
+	for idx := 0; idx < count; idx++ {
+		workers <- struct{}{}
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			<-workers
+			break
+		}
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			err := fn(idx)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(idx)
+	}
+	wg.Wait()
+	return firstErr
+}
+
 // generatePayload generates a JSON payload for a given Expected* resource
 // which is send to constellix API endpoint
 // Note: Costellix API is inconsistent. Sometimes it forces the inclusion of immutable fields
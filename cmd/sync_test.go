@@ -3,7 +3,11 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 type testExpectedResource struct {
@@ -198,6 +202,115 @@ func Test_Sync_update_dry(t *testing.T) {
 	}
 }
 
+func Test_Sync_update_dry_output_json(t *testing.T) {
+	er := &testExpectedResource{
+		Name:          "Field1",
+		Port:          80,
+		definedFields: []string{"Port"},
+	}
+	expCol := toResourceMatcher([]*testExpectedResource{er})
+
+	ar := &testActiveResource{
+		Name:         "Field1",
+		Port:         443,
+		constellixID: 999,
+	}
+	gone := &testActiveResource{
+		Name:         "Field2",
+		constellixID: 1000,
+	}
+	actCol := toResourceMatcher([]*testActiveResource{ar, gone})
+
+	reportToTestBuffer = true
+	planOutputFormat = planOutputJSON
+	defer func() {
+		reportToTestBuffer = false
+		testBuffer.Reset()
+		resetPlanOutput()
+	}()
+	err := Sync(expCol, actCol, false, false, "Test resources")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	if testBuffer.Len() != 0 {
+		t.Errorf("want no output before writePlanOutput, got %q", testBuffer.String())
+		return
+	}
+	err = writePlanOutput(nil)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	expected := `{
+  "plans": [
+    {
+      "title": "Test resources",
+      "entries": [
+        {
+          "action": "delete",
+          "resourceId": "Field2",
+          "constellixId": 1000
+        },
+        {
+          "action": "update",
+          "resourceId": "Field1",
+          "constellixId": 999,
+          "diffs": [
+            {
+              "field": "Port",
+              "old": "443",
+              "new": "80"
+            }
+          ]
+        }
+      ]
+    }
+  ],
+  "summary": {
+    "delete": 1,
+    "update": 1,
+    "create": 0
+  }
+}
+`
+	if testBuffer.String() != expected {
+		t.Errorf("want %q, got %q", expected, testBuffer.String())
+	}
+}
+
+func Test_writePlanOutput_yaml(t *testing.T) {
+	reportToTestBuffer = true
+	planOutputFormat = planOutputYAML
+	defer func() {
+		reportToTestBuffer = false
+		testBuffer.Reset()
+		resetPlanOutput()
+	}()
+	reportPlans("", false, &SyncPlan{
+		Title:   "Test resources",
+		Entries: []*PlanEntry{{Action: ActionCreate, ResourceID: "Field1"}},
+	})
+	err := writePlanOutput(nil)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	expected := `plans:
+    - title: Test resources
+      entries:
+        - action: create
+          resourceId: Field1
+summary:
+    delete: 0
+    update: 0
+    create: 1
+`
+	if testBuffer.String() != expected {
+		t.Errorf("want %q, got %q", expected, testBuffer.String())
+	}
+}
+
 func Test_Sync_create_doit(t *testing.T) {
 	er := &testExpectedResource{
 		Name: "Field1",
@@ -574,3 +687,201 @@ func Test_Generate_payload_geoproximity_empty(t *testing.T) {
 		return
 	}
 }
+
+func Test_reportPlans_with_title(t *testing.T) {
+	reportToTestBuffer = true
+	defer func() {
+		reportToTestBuffer = false
+		testBuffer.Reset()
+		resetPlanOutput()
+	}()
+	plans := []*SyncPlan{
+		{Title: "Pools", Entries: []*PlanEntry{
+			{Action: ActionCreate, ResourceID: "Field1"},
+			{Action: ActionDelete, ResourceID: "Field2", ConstellixID: 7},
+		}},
+		{Title: "IP filters", Entries: []*PlanEntry{{Action: ActionOK, ResourceID: "Field3"}}},
+	}
+	reportPlans("", true, plans...)
+
+	output := stripBashColors(testBuffer.String())
+	expected := "Pools,create,Field1,\n" +
+		",delete,Field2,Resource ID 7\n" +
+		"IP filters,ok,Field3,\n"
+	if output != expected {
+		t.Errorf("want %q, got %q", expected, output)
+	}
+}
+
+func Test_finishSync_detailed_exitcode(t *testing.T) {
+	reportToTestBuffer = true
+	defer func() {
+		reportToTestBuffer = false
+		testBuffer.Reset()
+		resetPlanOutput()
+		exitCode = 0
+	}()
+	plan := &SyncPlan{Entries: []*PlanEntry{{Action: ActionOK, ResourceID: "Field1"}}}
+	tests := []struct {
+		detailedExitCode bool
+		action           ResourceAction
+		applied          bool
+		want             int
+	}{
+		{false, ActionUpate, false, 0},
+		{true, ActionOK, false, 0},
+		{true, ActionUpate, false, exitCodeChangesPending},
+		{true, ActionUpate, true, 0},
+	}
+	for _, tt := range tests {
+		resetPlanOutput()
+		exitCode = 0
+		detailedExitCode = tt.detailedExitCode
+		plan.Entries[0].Action = tt.action
+		reportPlans("", false, plan)
+		markPendingChanges(tt.applied, plan)
+		err := finishSync()
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+			continue
+		}
+		if exitCode != tt.want {
+			t.Errorf(
+				"detailedExitCode=%t, action=%s, applied=%t: want exit code %d, got %d",
+				tt.detailedExitCode, tt.action, tt.applied, tt.want, exitCode,
+			)
+		}
+	}
+}
+
+// orderedTestResource records sync calls of all resources in a shared log
+type orderedTestResource struct {
+	id     string
+	log    *[]string
+	mu     *sync.Mutex
+	active *int
+	peak   *int
+	err    error
+}
+
+func (r *orderedTestResource) record(action string) error {
+	r.mu.Lock()
+	*r.active++
+	if *r.active > *r.peak {
+		*r.peak = *r.active
+	}
+	r.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*r.active--
+	*r.log = append(*r.log, action+" "+r.id)
+	return r.err
+}
+
+func (r *orderedTestResource) GetResourceID() string                { return r.id }
+func (r *orderedTestResource) GetResource() interface{}             { return r }
+func (r *orderedTestResource) GetConstellixID() int                 { return 1 }
+func (r *orderedTestResource) GetDefinedStructFieldNames() []string { return nil }
+func (r *orderedTestResource) GetImmutableStructFields() []string   { return nil }
+func (r *orderedTestResource) SyncResourceCreate() error            { return r.record("create") }
+func (r *orderedTestResource) SyncResourceUpdate(int) error         { return r.record("update") }
+func (r *orderedTestResource) SyncResourceDelete(int) error         { return r.record("delete") }
+
+func Test_syncChanges_parallelism_order(t *testing.T) {
+	var log []string
+	var mu sync.Mutex
+	var active, peak int
+	newResource := func(id string) *orderedTestResource {
+		return &orderedTestResource{id: id, log: &log, mu: &mu, active: &active, peak: &peak}
+	}
+	toDelete := []IActiveResource{}
+	toUpdate := map[IExpectedResource]int{}
+	toCreate := []IExpectedResource{}
+	for i := 1; i <= 4; i++ {
+		toDelete = append(toDelete, newResource(fmt.Sprintf("default-%d 0)", i)), newResource(fmt.Sprintf("geo-%d 5)", i)))
+		toUpdate[newResource(fmt.Sprintf("update-%d", i))] = i
+		toCreate = append(toCreate, newResource(fmt.Sprintf("geo-%d 5)", i)), newResource(fmt.Sprintf("default-%d 0)", i)))
+	}
+
+	syncParallelism = 3
+	defer func() {
+		syncParallelism = 1
+	}()
+	err := syncChanges(toDelete, toUpdate, toCreate)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	if peak < 2 || peak > 3 {
+		t.Errorf("want 2-3 concurrent calls, got %d", peak)
+	}
+	phases := []string{"delete geo-", "delete default-", "update update-", "create default-", "create geo-"}
+	if len(log) != 4*len(phases) {
+		t.Errorf("want %d calls, got %d: %q", 4*len(phases), len(log), log)
+		return
+	}
+	for idx, call := range log {
+		if !strings.HasPrefix(call, phases[idx/4]) {
+			t.Errorf("want call %d to start with %q, got %q in %q", idx, phases[idx/4], call, log)
+			return
+		}
+	}
+}
+
+func Test_runConcurrently_error(t *testing.T) {
+	var calls int32
+	err := runConcurrently(10, func(idx int) error {
+		atomic.AddInt32(&calls, 1)
+		if idx == 2 {
+			return fmt.Errorf("failed %d", idx)
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "failed 2" {
+		t.Errorf("want error %q, got %v", "failed 2", err)
+	}
+	if calls != 3 {
+		t.Errorf("want 3 calls, got %d", calls)
+	}
+}
+
+func Test_syncChanges_keep_going(t *testing.T) {
+	var log []string
+	var mu sync.Mutex
+	var active, peak int
+	newResource := func(id string, err error) *orderedTestResource {
+		return &orderedTestResource{id: id, log: &log, mu: &mu, active: &active, peak: &peak, err: err}
+	}
+	apiErr := fmt.Errorf("unable to delete: %w", &APIError{StatusCode: 400, ExpectedStatusCode: 204, Body: `{"errors":["in use"]}`})
+	toDelete := []IActiveResource{newResource("a", apiErr)}
+	toUpdate := map[IExpectedResource]int{newResource("b", nil): 1}
+	toCreate := []IExpectedResource{newResource("c", nil)}
+
+	reportToTestBuffer = true
+	syncKeepGoing = true
+	defer func() {
+		reportToTestBuffer = false
+		testBuffer.Reset()
+		syncKeepGoing = false
+	}()
+	err := syncChanges(toDelete, toUpdate, toCreate)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	if len(log) != 3 {
+		t.Errorf("want 3 calls, got %q", log)
+		return
+	}
+	err = reportSyncResults()
+	if err == nil || err.Error() != "1 of 3 changes failed" {
+		t.Errorf("want error %q, got %v", "1 of 3 changes failed", err)
+	}
+	output := stripBashColors(testBuffer.String())
+	for _, want := range []string{"delete,a,failed,", "want 204", "in use", "update,b,succeeded,", "create,c,succeeded,"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("want %q in %q", want, output)
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// Names of generated Terraform files
+const (
+	terraformProviderFile       = "provider.tf"
+	terraformDomainsFile        = "domains.tf"
+	terraformSonarChecksFile    = "sonar_checks.tf"
+	terraformGeoProximitiesFile = "geoproximities.tf"
+	terraformImportsFile        = "imports.sh"
+)
+
+const terraformProvider = `terraform {
+  required_providers {
+    constellix = {
+      source = "Constellix/constellix"
+    }
+  }
+}
+
+variable "constellix_api_key" {
+  type      = string
+  sensitive = true
+}
+
+variable "constellix_secret_key" {
+  type      = string
+  sensitive = true
+}
+
+provider "constellix" {
+  apikey    = var.constellix_api_key
+  secretkey = var.constellix_secret_key
+}
+`
+
+// IDs of GTD regions in the Constellix Terraform provider
+var terraformGTDRegions = map[string]int{
+	"default":       1,
+	"europe":        2,
+	"us-east":       3,
+	"us-west":       4,
+	"asia-pacific":  5,
+	"oceania":       6,
+	"south-america": 7,
+}
+
+// Values of record_option for record modes
+var terraformRecordOptions = map[string]string{
+	"standard":            "roundRobin",
+	"failover":            "failover",
+	"roundrobin-failover": "roundRobinFailover",
+	"pools":               "pools",
+}
+
+// Values of failover_type for failover modes
+var terraformFailoverTypes = map[string]int{
+	"normal":  1,
+	"off":     2,
+	"one-way": 3,
+}
+
+// Values of redirect_type_id for HTTP redirection types
+var terraformRedirectTypes = map[string]int{
+	"hidden": 1,
+	"301":    2,
+	"302":    3,
+}
+
+// terraformExport collects Terraform configuration for the Constellix
+// provider and commands which import existing resources into the state
+type terraformExport struct {
+	files   map[string][]*hclBlock
+	imports []string
+	issues  []*importIssue
+	names   hclNames
+	// Terraform addresses of exported resources by their IDs, used to
+	// reference them instead of IDs
+	checks         map[int]string
+	geoproximities map[int]string
+}
+
+func newTerraformExport() *terraformExport {
+	return &terraformExport{
+		files:          map[string][]*hclBlock{},
+		names:          hclNames{},
+		checks:         map[int]string{},
+		geoproximities: map[int]string{},
+	}
+}
+
+// addResource adds the resource block to the file and the import command.
+// Returns the address of the resource
+func (e *terraformExport) addResource(file string, block *hclBlock, importID string) string {
+	address := block.labels[0] + "." + block.labels[1]
+	e.files[file] = append(e.files[file], block)
+	e.imports = append(e.imports, fmt.Sprintf("terraform import %s %s", address, importID))
+	return address
+}
+
+func (e *terraformExport) addIssue(location string, record string, reason string, args ...interface{}) {
+	e.issues = append(e.issues, &importIssue{Location: location, Record: record, Reason: fmt.Sprintf(reason, args...)})
+}
+
+func (e *terraformExport) addGeoProximities(geops []*GeoProximity) {
+	for _, geop := range geops {
+		block := newHCLBlock("resource", "constellix_geo_proximity", e.names.name("constellix_geo_proximity", geop.Name))
+		block.set("name", geop.Name).
+			setNonZero("country", geop.Country).
+			setNonZero("region", geop.Region).
+			setNonZero("city", geop.City).
+			set("latitude", geop.Latitude).
+			set("longitude", geop.Longitude)
+		e.geoproximities[geop.ID] = e.addResource(terraformGeoProximitiesFile, block, fmt.Sprint(geop.ID))
+	}
+}
+
+func (e *terraformExport) addSonarHTTPChecks(checks []*SonarHTTPCheck) {
+	for _, check := range checks {
+		block := newHCLBlock("resource", "constellix_http_check", e.names.name("constellix_http_check", check.Name))
+		block.set("name", check.Name).
+			set("host", check.Host).
+			set("ip_version", check.IPVersion).
+			set("port", check.Port).
+			set("protocol_type", check.ProtocolType).
+			set("check_sites", check.CheckSites).
+			set("interval", check.Interval).
+			setNonZero("interval_policy", check.MonitorIntervalPolicy).
+			setNonZero("verification_policy", check.VerificationPolicy).
+			setNonZero("fqdn", check.FQDN).
+			setNonZero("path", check.Path).
+			setNonZero("search_string", check.SearchString).
+			setNonZero("expected_status_code", check.ExpectedStatusCode)
+		e.checks[check.ID] = e.addResource(terraformSonarChecksFile, block, fmt.Sprint(check.ID))
+	}
+}
+
+func (e *terraformExport) addSonarTCPChecks(checks []*SonarTCPCheck) {
+	for _, check := range checks {
+		block := newHCLBlock("resource", "constellix_tcp_check", e.names.name("constellix_tcp_check", check.Name))
+		block.set("name", check.Name).
+			set("host", check.Host).
+			set("ip_version", check.IPVersion).
+			set("port", check.Port).
+			set("check_sites", check.CheckSites).
+			set("interval", check.Interval).
+			setNonZero("interval_policy", check.MonitorIntervalPolicy).
+			setNonZero("verification_policy", check.VerificationPolicy).
+			setNonZero("string_to_send", check.StringToSend).
+			setNonZero("string_to_receive", check.StringToReceive)
+		e.checks[check.ID] = e.addResource(terraformSonarChecksFile, block, fmt.Sprint(check.ID))
+	}
+}
+
+// addDomain adds the domain and its DNS records. Records are written to a
+// separate file for every domain
+func (e *terraformExport) addDomain(domain *DNSDomain, records []*DNSRecord) {
+	block := newHCLBlock("resource", "constellix_domain", e.names.name("constellix_domain", domain.Name))
+	block.set("name", domain.Name).
+		set("has_gtd_regions", domain.GTDEnabled).
+		set("has_geoip", domain.GeoIPEnabled).
+		setNonZero("note", domain.Note)
+	domainRef := hclExpr(e.addResource(terraformDomainsFile, block, fmt.Sprint(domain.ID)) + ".id")
+
+	file := "dns_" + domain.Name + ".tf"
+	// Apex NS records are managed by Constellix
+	for _, record := range withoutApexNS(records) {
+		block, ok := e.recordBlock(domain.Name, domainRef, record)
+		if ok {
+			e.addResource(file, block, fmt.Sprintf("domains:%d:%d", domain.ID, record.ID))
+		}
+	}
+}
+
+// checkRef returns a reference to the exported Sonar check or its ID
+func (e *terraformExport) checkRef(id int) interface{} {
+	if address, ok := e.checks[id]; ok {
+		return hclExpr(address + ".id")
+	}
+	return id
+}
+
+// recordBlock converts the DNS record to a resource of the Constellix
+// provider. Returns false if the record can't be converted
+func (e *terraformExport) recordBlock(domainName string, domainRef hclExpr, record *DNSRecord) (*hclBlock, bool) {
+	resourceType := "constellix_" + strings.ToLower(record.Type) + "_record"
+	if record.Type == "HTTP" {
+		resourceType = "constellix_http_redirection_record"
+	}
+	nameParts := []string{domainName, record.Name, record.Type}
+	if record.Name == "" {
+		nameParts[1] = "apex"
+	}
+	if record.Region != "" && record.Region != "default" {
+		nameParts = append(nameParts, record.Region)
+	}
+	if record.GeoProximity != nil {
+		nameParts = append(nameParts, fmt.Sprint("gp", record.GeoProximity))
+	}
+
+	block := newHCLBlock("resource", resourceType, "")
+	block.set("domain_id", domainRef).
+		set("source_type", "domains").
+		set("name", record.Name).
+		set("ttl", record.TTL)
+	if record.Region != "" && record.Region != "default" {
+		region, ok := terraformGTDRegions[record.Region]
+		if !ok {
+			e.addIssue(domainName, record.GetResourceID(), "unknown GTD region %s", record.Region)
+			return nil, false
+		}
+		block.set("gtd_region", region)
+	}
+	if slices.Contains(supportedPoolTypes, record.Type) || record.Type == "ANAME" {
+		block.set("record_option", terraformRecordOptions[record.Mode])
+	}
+	block.setNonZero("note", record.Notes)
+	if !record.Enabled {
+		e.addIssue(domainName, record.GetResourceID(), "record is disabled, the provider creates it enabled")
+	}
+	if record.GeoProximity != nil || record.IPFilter != nil || record.GeoFailover {
+		geo := block.block("geo_location")
+		if record.GeoProximity != nil {
+			id := toInt(record.GeoProximity)
+			if address, ok := e.geoproximities[id]; ok {
+				geo.set("geo_ip_proximity", hclExpr(address+".id"))
+			} else {
+				geo.set("geo_ip_proximity", id)
+			}
+		}
+		if record.IPFilter != nil {
+			geo.set("geo_ip_user_region", toInt(record.IPFilter))
+			geo.set("drop", record.IPFilterDrop)
+		}
+		geo.setNonZero("geo_ip_failover", record.GeoFailover)
+	}
+
+	disabled := func(enabled bool) bool { return !enabled }
+	switch value := record.Value.(type) {
+	case []*DNSStandardItemValue:
+		if record.Type == "CNAME" {
+			if len(value) > 0 {
+				block.set("host", value[0].Value)
+			}
+			break
+		}
+		for _, item := range value {
+			block.block("roundrobin").set("value", item.Value).set("disable_flag", disabled(item.Enabled))
+		}
+	case *DNSFailoverValue:
+		failoverType, ok := terraformFailoverTypes[value.Mode]
+		if !ok {
+			e.addIssue(domainName, record.GetResourceID(), "unknown failover mode %s", value.Mode)
+			return nil, false
+		}
+		failover := block.block("record_failover")
+		failover.set("failover_type", failoverType).set("disable_flag", disabled(value.Enabled))
+		for _, item := range value.Values {
+			failover.block("values").
+				set("value", item.Value).
+				set("sort_order", item.Order).
+				set("check_id", e.checkRef(item.SonarCheckID)).
+				set("disable_flag", disabled(item.Enabled))
+		}
+	case []*DNSFailoverItemValue:
+		for _, item := range value {
+			block.block("roundrobin_failover").
+				set("value", item.Value).
+				set("sort_order", item.Order).
+				set("check_id", e.checkRef(item.SonarCheckID)).
+				set("disable_flag", disabled(item.Enabled))
+		}
+	case []int:
+		block.set("pools", value)
+	case []*DNSMXStandardItemValue:
+		for _, item := range value {
+			block.block("roundrobin").
+				set("value", item.Server).
+				set("level", item.Priority).
+				set("disable_flag", disabled(item.Enabled))
+		}
+	case []*DNSSRVStandardItemValue:
+		for _, item := range value {
+			block.block("roundrobin").
+				set("value", item.Host).
+				set("port", item.Port).
+				set("priority", item.Priority).
+				set("weight", item.Weight).
+				set("disable_flag", disabled(item.Enabled))
+		}
+	case []*DNSCAAStandardItemValue:
+		for _, item := range value {
+			block.block("roundrobin").
+				set("flag", fmt.Sprint(item.Flag)).
+				set("tag", item.Tag).
+				set("data", item.Data).
+				set("disable_flag", disabled(item.Enabled))
+		}
+	case []*DNSNAPTRStandardItemValue:
+		for _, item := range value {
+			block.block("roundrobin").
+				set("order", item.Order).
+				set("preference", item.Preference).
+				set("flags", item.Flags).
+				set("service", item.Service).
+				set("regular_expression", item.RegularExpression).
+				set("replacement", item.Replacement).
+				set("disable_flag", disabled(item.Enabled))
+		}
+	case []*DNSCERTStandardItemValue:
+		for _, item := range value {
+			block.block("roundrobin").
+				set("certificate_type", item.CertificateType).
+				set("key_tag", item.KeyTag).
+				set("algorithm", item.Algorithm).
+				set("certificate", item.Certificate).
+				set("disable_flag", disabled(item.Enabled))
+		}
+	case []*DNSHINFOStandardItemValue:
+		for _, item := range value {
+			block.block("roundrobin").
+				set("cpu", item.CPU).
+				set("os", item.OS).
+				set("disable_flag", disabled(item.Enabled))
+		}
+	case []*DNSRPStandardItemValue:
+		for _, item := range value {
+			block.block("roundrobin").
+				set("mailbox", item.Mailbox).
+				set("txt", item.TXT).
+				set("disable_flag", disabled(item.Enabled))
+		}
+	case *DNSHTTPStandardItemValue:
+		redirectType, ok := terraformRedirectTypes[value.RedirectType]
+		if !ok {
+			e.addIssue(domainName, record.GetResourceID(), "unknown redirect type %s", value.RedirectType)
+			return nil, false
+		}
+		block.set("url", value.URL).
+			set("redirect_type_id", redirectType).
+			set("hardlink_flag", value.Hard).
+			setNonZero("title", value.Title).
+			setNonZero("keywords", value.Keywords).
+			setNonZero("description", value.Description)
+	default:
+		e.addIssue(domainName, record.GetResourceID(), "unsupported value %T", record.Value)
+		return nil, false
+	}
+	block.labels[1] = e.names.name(resourceType, nameParts...)
+	return block, true
+}
+
+// write saves Terraform files and the script with import commands to the
+// directory
+func (e *terraformExport) write(dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(dir, terraformProviderFile), []byte(terraformProvider), 0644)
+	if err != nil {
+		return err
+	}
+	files := maps.Keys(e.files)
+	sort.Strings(files)
+	for _, file := range files {
+		err = os.WriteFile(filepath.Join(dir, file), []byte(renderHCL(e.files[file])), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	script := "#!/bin/sh\n# Import existing Constellix resources into Terraform state\nset -e\n\n" +
+		strings.Join(e.imports, "\n") + "\n"
+	err = os.WriteFile(filepath.Join(dir, terraformImportsFile), []byte(script), 0755)
+	if err != nil {
+		return err
+	}
+	logger.Printf("Terraform configuration for %d resources saved to %s\n", len(e.imports), dir)
+	return nil
+}
+
+// exportTerraform discovers domains, DNS records, Sonar HTTP and TCP checks
+// and GeoProximities and converts them to Terraform configuration. If
+// domainNames is not empty, other domains are skipped
+func exportTerraform(domainNames []string) (*terraformExport, error) {
+	e := newTerraformExport()
+
+	geops, err := GetGeoProximities()
+	if err != nil {
+		return nil, err
+	}
+	e.addGeoProximities(geops)
+
+	httpChecks, err := GetSonarHTTPChecks()
+	if err != nil {
+		return nil, err
+	}
+	e.addSonarHTTPChecks(httpChecks)
+
+	tcpChecks, err := GetSonarTCPChecks()
+	if err != nil {
+		return nil, err
+	}
+	e.addSonarTCPChecks(tcpChecks)
+
+	domains, err := GetDNSDomains()
+	if err != nil {
+		return nil, err
+	}
+	for _, domain := range domains {
+		if len(domainNames) > 0 && !slices.Contains(domainNames, domain.Name) {
+			continue
+		}
+		records, err := GetDNSRecords(domain.ID)
+		if err != nil {
+			return nil, err
+		}
+		e.addDomain(domain, records)
+	}
+	return e, nil
+}
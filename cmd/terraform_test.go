@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_exportTerraform(t *testing.T) {
+	sonar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/http":
+			w.Write([]byte(`[{"id":1,"name":"web check","host":"example.com","port":443,"protocolType":"HTTPS",` +
+				`"ipVersion":"IPV4","interval":"ONEMINUTE","checkSites":[1,2],"expectedStatusCode":200,"path":"/${x}"}]`))
+		case "/tcp":
+			w.Write([]byte(`[{"id":2,"name":"smtp","host":"192.0.2.25","port":25,"ipVersion":"IPV4",` +
+				`"interval":"ONEMINUTE","checkSites":[1]}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer sonar.Close()
+	dns := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/geoproximities":
+			w.Write([]byte(v4TestResponse(`[{"id":5,"name":"amsterdam","latitude":52.37,"longitude":4.89}]`)))
+		case "/domains":
+			w.Write([]byte(v4TestResponse(`[{"id":10,"name":"example.com","gtd":true},{"id":11,"name":"other.com"}]`)))
+		case "/domains/10/records":
+			w.Write([]byte(v4TestResponse(`[` +
+				`{"id":100,"name":"www","type":"A","ttl":300,"mode":"standard","region":"default","enabled":true,` +
+				`"geoproximity":{"id":5,"name":"amsterdam"},"value":[{"value":"192.0.2.1","enabled":true}]},` +
+				`{"id":101,"name":"www","type":"A","ttl":300,"mode":"failover","region":"europe","enabled":true,` +
+				`"value":{"mode":"normal","enabled":true,"values":[` +
+				`{"value":"192.0.2.2","order":1,"sonarCheckId":1,"enabled":true},` +
+				`{"value":"192.0.2.3","order":2,"sonarCheckId":99,"enabled":true}]}},` +
+				`{"id":102,"name":"","type":"MX","ttl":3600,"mode":"standard","region":"default","enabled":true,` +
+				`"value":[{"server":"mail.example.com.","priority":10,"enabled":true}]},` +
+				`{"id":103,"name":"api","type":"CNAME","ttl":60,"mode":"standard","region":"default","enabled":true,` +
+				`"value":[{"value":"www.example.com.","enabled":true}]},` +
+				`{"id":104,"name":"go","type":"HTTP","ttl":60,"mode":"standard","region":"default","enabled":true,` +
+				`"value":{"url":"https://example.org","redirectType":"unknown","hard":true}}]`)))
+		default:
+			w.Write([]byte(v4TestResponse(`[]`)))
+		}
+	}))
+	defer dns.Close()
+
+	originalSonarRESTAPIBaseURL := sonarRESTAPIBaseURL
+	originalDNSRESTAPIBaseURL := dnsRESTAPIBaseURL
+	resetCache()
+	defer func() {
+		sonarRESTAPIBaseURL = originalSonarRESTAPIBaseURL
+		dnsRESTAPIBaseURL = originalDNSRESTAPIBaseURL
+		resetCache()
+	}()
+	sonarRESTAPIBaseURL = sonar.URL
+	dnsRESTAPIBaseURL = dns.URL
+
+	e, err := exportTerraform([]string{"example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	err = e.write(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.issues) != 1 || e.issues[0].Record != `HTTP "go" (default, 0)` {
+		t.Errorf("expected HTTP record issue, got %+v", e.issues)
+	}
+
+	expected := map[string][]string{
+		terraformProviderFile: {`source = "Constellix/constellix"`},
+		terraformSonarChecksFile: {
+			`resource "constellix_http_check" "web_check" {`,
+			`  check_sites          = [1, 2]`,
+			`  path                 = "/$${x}"`,
+			`resource "constellix_tcp_check" "smtp" {`,
+		},
+		terraformGeoProximitiesFile: {`resource "constellix_geo_proximity" "amsterdam" {`, `  latitude  = 52.37`},
+		terraformDomainsFile:        {`resource "constellix_domain" "example_com" {`, `  has_gtd_regions = true`},
+		"dns_example.com.tf": {
+			`resource "constellix_a_record" "example_com_www_a_gp5" {`,
+			`  domain_id     = constellix_domain.example_com.id`,
+			`    geo_ip_proximity = constellix_geo_proximity.amsterdam.id`,
+			`resource "constellix_a_record" "example_com_www_a_europe" {`,
+			`  gtd_region    = 2`,
+			`  record_option = "failover"`,
+			`      check_id     = constellix_http_check.web_check.id`,
+			`      check_id     = 99`,
+			`resource "constellix_mx_record" "example_com_apex_mx" {`,
+			`    level        = 10`,
+			`  host          = "www.example.com."`,
+		},
+		terraformImportsFile: {
+			"terraform import constellix_geo_proximity.amsterdam 5\n",
+			"terraform import constellix_http_check.web_check 1\n",
+			"terraform import constellix_domain.example_com 10\n",
+			"terraform import constellix_a_record.example_com_www_a_europe domains:10:101\n",
+			"terraform import constellix_cname_record.example_com_api_cname domains:10:103\n",
+		},
+	}
+	for file, lines := range expected {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for _, line := range lines {
+			if !strings.Contains(string(data), line) {
+				t.Errorf("%s: expected %q in:\n%s", file, line, data)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "dns_other.com.tf")); err == nil {
+		t.Error("other.com is not expected to be exported")
+	}
+}
+
+func Test_hclNames(t *testing.T) {
+	names := hclNames{}
+	for _, c := range []struct {
+		parts    []string
+		expected string
+	}{
+		{[]string{"example.com", "*.wild", "A"}, "example_com_wild_a"},
+		{[]string{"example.com", "*.wild", "A"}, "example_com_wild_a_2"},
+		{[]string{"1st"}, "r_1st"},
+	} {
+		if name := names.name("constellix_a_record", c.parts...); name != c.expected {
+			t.Errorf("expected %s, got %s", c.expected, name)
+		}
+	}
+}
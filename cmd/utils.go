@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	libURL "net/url"
@@ -16,6 +18,11 @@ import (
 
 const rateLimitWaitTime = 5
 
+// rateLimitedUntil is the time until which all API requests wait after the
+// rate limit was exceeded, so concurrent requests back off together
+var rateLimitedUntil time.Time
+var rateLimitMu sync.Mutex
+
 const Reset = "\033[0m"
 const Red = "\033[31m"
 const Green = "\033[32m"
@@ -104,12 +111,36 @@ func getTag(obj interface{}, fieldName string, tagType string) string {
 	return ""
 }
 
+// APIError is returned when Constellix API responds with unexpected status code.
+// Body contains the error details provided by Constellix
+type APIError struct {
+	StatusCode         int
+	ExpectedStatusCode int
+	Body               string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("unexpected status code %d, want %d", e.StatusCode, e.ExpectedStatusCode)
+}
+
 // makeSimpleAPIRequest makes a simple API request, normally to the Sonar API as
 // it doesn't support pagination
 func makeSimpleAPIRequest(method string, url string, payload io.Reader, expectedStatusCode int) (respBody []byte, err error) {
 	client := &http.Client{
 		Timeout: 3 * time.Minute,
 	}
+	// Security token contains a timestamp, wait before building the request
+	waitForRateLimit()
+
+	// Payload is sent again when the request is retried
+	var payloadBytes []byte
+	if payload != nil {
+		payloadBytes, err = io.ReadAll(payload)
+		if err != nil {
+			return nil, err
+		}
+		payload = bytes.NewReader(payloadBytes)
+	}
 	req, err := http.NewRequest(method, url, payload)
 
 	if err != nil {
@@ -120,10 +151,6 @@ func makeSimpleAPIRequest(method string, url string, payload io.Reader, expected
 	if logLevel > 0 {
 		logger.Printf("  requesting %s %s ...\n", method, url)
 		if payload != nil {
-			payloadBytes, err := io.ReadAll(payload)
-			if err != nil {
-				return nil, err
-			}
 			logger.Println("  payload: " + string(payloadBytes))
 		} else {
 			logger.Println("  no payload")
@@ -136,18 +163,22 @@ func makeSimpleAPIRequest(method string, url string, payload io.Reader, expected
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusTooManyRequests {
+		var retryPayload io.Reader
+		if payload != nil {
+			retryPayload = bytes.NewReader(payloadBytes)
+		}
 		// Proper way to handle rate limit would be to parse the X-Ratelimit-Reset header
 		if resetHeaderValue, ok := resp.Header["X-Ratelimit-Reset"]; ok {
 			if sleep, err := strconv.ParseInt(resetHeaderValue[0], 10, 64); err == nil {
 				logger.Printf("Rate limit exceeded, waiting %d seconds...\n", sleep)
-				time.Sleep(time.Duration(sleep) * time.Second)
-				return makeSimpleAPIRequest(method, url, payload, expectedStatusCode)
+				pauseForRateLimit(time.Duration(sleep) * time.Second)
+				return makeSimpleAPIRequest(method, url, retryPayload, expectedStatusCode)
 			}
 		}
 		// If the header is not present, we will wait for a fixed time
 		logger.Printf("Rate limit exceeded, waiting %d seconds...\n", rateLimitWaitTime)
-		time.Sleep(time.Duration(rateLimitWaitTime) * time.Second)
-		return makeSimpleAPIRequest(method, url, payload, expectedStatusCode)
+		pauseForRateLimit(time.Duration(rateLimitWaitTime) * time.Second)
+		return makeSimpleAPIRequest(method, url, retryPayload, expectedStatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -156,7 +187,7 @@ func makeSimpleAPIRequest(method string, url string, payload io.Reader, expected
 	}
 	if resp.StatusCode != expectedStatusCode {
 		logger.Println(string(body))
-		return body, fmt.Errorf("unexpected status code %d, want %d", resp.StatusCode, expectedStatusCode)
+		return body, &APIError{StatusCode: resp.StatusCode, ExpectedStatusCode: expectedStatusCode, Body: string(body)}
 	}
 	if logLevel > 1 {
 		logger.Println(method, url, resp.StatusCode)
@@ -165,6 +196,24 @@ func makeSimpleAPIRequest(method string, url string, payload io.Reader, expected
 	return body, nil
 }
 
+// pauseForRateLimit makes all API requests wait for the duration
+func pauseForRateLimit(duration time.Duration) {
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+	until := time.Now().Add(duration)
+	if until.After(rateLimitedUntil) {
+		rateLimitedUntil = until
+	}
+}
+
+// waitForRateLimit blocks until the rate limit pause is over
+func waitForRateLimit() {
+	rateLimitMu.Lock()
+	until := rateLimitedUntil
+	rateLimitMu.Unlock()
+	time.Sleep(time.Until(until))
+}
+
 // makev4APIRequest makes a request to the v4 API, which supports pagination.
 // It returns a slice of response bodies, one for each page.
 func makev4APIRequest(method string, url string, payload io.Reader, expectedStatusCode int) (respBodys [][]byte, err error) {
@@ -172,7 +221,7 @@ func makev4APIRequest(method string, url string, payload io.Reader, expectedStat
 	for next {
 		data, err := makeSimpleAPIRequest(method, url, payload, expectedStatusCode)
 		if err != nil {
-			return nil, fmt.Errorf("unable to retrieve resource: %s", err)
+			return nil, fmt.Errorf("unable to retrieve resource: %w", err)
 		}
 		if len(data) != 0 {
 			resp := DNSv4Response{}
@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -168,3 +172,27 @@ func TestGetJSONTagsFromStruct_with_yaml(t *testing.T) {
 		return
 	}
 }
+
+func TestMakeSimpleAPIRequest_rate_limit_retry(t *testing.T) {
+	var payloads []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		payloads = append(payloads, string(body))
+		if len(payloads) == 1 {
+			w.Header().Set("X-Ratelimit-Reset", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	_, err := makeSimpleAPIRequest("POST", ts.URL, strings.NewReader(`{"name":"test"}`), http.StatusCreated)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	if len(payloads) != 2 || payloads[0] != payloads[1] {
+		t.Errorf("want the same payload in 2 requests, got %q", payloads)
+	}
+}